@@ -0,0 +1,115 @@
+package lib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseAreFile(t *testing.T) {
+	are := `#AREA
+midgaard.are~
+Midgaard~
+{ All } Diku    Midgaard~
+3000 3399
+
+#MOBILES
+#3000
+wizard~
+the wizard~
+A wizard walks around behind the counter, talking to himself.
+~
+The wizard looks old and senile.
+~
+human~
+ABV DFJ 900 0
+33 20 22d22+700 33d9+330 2d8+10 slash
+-10 -10 -10 -10
+0 0 0 0
+stand sleep male 100
+0 0 0 0
+#0
+
+#OBJECTS
+#3000
+barrel beer~
+a barrel of beer~
+A beer barrel has been left here.~
+wood~
+drink_container 0 A
+300 300 'beer' 0 0
+5 60 200 P
+#0
+
+#ROOMS
+#3001
+The Temple Of Mota~
+You are in the southern end of the temple hall.
+~
+0 CDKS 9
+D0
+~
+door~
+3 -1 3054
+S
+#3002
+The Desert~
+Sand, everywhere.
+~
+0 0 10
+S
+#0
+
+#RESETS
+M 0 3000 1 3001 1
+S
+
+#$
+`
+	name := filepath.Join(t.TempDir(), "midgaard.are")
+	if err := ioutil.WriteFile(name, []byte(are), 0600); err != nil {
+		t.Fatal(err)
+	}
+	area, err := ParseAreFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if z := area.Zone; z.Number != 30 || z.Name != "Midgaard" || z.BottomNumber != 3000 || z.TopNumber != 3399 {
+		t.Errorf("unexpected zone: %+v", z)
+	}
+	if len(area.Rooms) != 2 || len(area.Mobs) != 1 {
+		t.Fatalf("expected 2 rooms and 1 mob, got %d and %d", len(area.Rooms), len(area.Mobs))
+	}
+	r := area.Rooms[0]
+	if !reflect.DeepEqual(r.Bits, []string{"NOMOB", "INDOORS", "PEACEFUL"}) || r.Sector != "FLYING" || r.Zone != 30 {
+		t.Errorf("unexpected room: %+v", r)
+	}
+	if r := area.Rooms[1]; r.Sector != "FIELD" || r.SectorRaw != "DESERT" {
+		t.Errorf("expected DESERT to be converted to FIELD, got %+v", r)
+	}
+	if len(r.Exits) != 1 || r.Exits[0].DoorFlag != "NORMAL" || r.Exits[0].Destination != 3054 {
+		t.Errorf("unexpected exits: %+v", r.Exits)
+	}
+	m := area.Mobs[0]
+	if !reflect.DeepEqual(m.Actions, []string{"SENTINEL"}) || !reflect.DeepEqual(m.Affections, []string{"DETECT_INVIS", "INFRAVISION"}) {
+		t.Errorf("unexpected mob flags: %q %q", m.Actions, m.Affections)
+	}
+	if m.THAC0 != 0 || m.HP != "22d22+700" || m.BareHandAttack != "slash" || m.DefaultPosition != "POSITION_SLEEPING" || m.Gold != 100 {
+		t.Errorf("unexpected mob: %+v", m)
+	}
+	expected := []Unconverted{
+		{Record: "area", Number: 30, Field: "credits", Value: "{ All } Diku    Midgaard"},
+		{Record: "mob", Number: 3000, Field: "race", Value: "human"},
+		{Record: "mob", Number: 3000, Field: "act", Value: "NOPURGE"},
+		{Record: "mob", Number: 3000, Field: "affected_by", Value: "DETECT_HIDDEN"},
+		{Record: "mob", Number: 3000, Field: "mana", Value: "33d9+330"},
+		{Record: "area", Number: 30, Field: "section", Value: "#OBJECTS"},
+		{Record: "room", Number: 3001, Field: "room_flags", Value: "LAW"},
+		{Record: "room", Number: 3001, Field: "exit_flags", Value: "North NOPASS"},
+		{Record: "area", Number: 30, Field: "section", Value: "#RESETS"},
+	}
+	if !reflect.DeepEqual(area.Unconverted, expected) {
+		t.Errorf("unexpected unconverted data:\n%+v\nexpected\n%+v", area.Unconverted, expected)
+	}
+}
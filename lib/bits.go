@@ -2,10 +2,13 @@ package lib
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 )
 
-// BitsTonames converts a bitvector into a list of bit names
+// BitsToNames converts a bitvector into a list of bit names.  Only bits up to
+// maxbit are converted from number-style bitvectors.
 func BitsToNames(vector string, maxbit int, bits map[int]string, chars map[rune]string) ([]string, error) {
 	values := []string{}
 	if num, err := strconv.Atoi(vector); err == nil {
@@ -26,3 +29,220 @@ func BitsToNames(vector string, maxbit int, bits map[int]string, chars map[rune]
 	}
 	return values, nil
 }
+
+// AllBitsToNames is like BitsToNames, but it converts every bit.  Bits that
+// don't have a name are called BIT_n, where n is the number of the bit
+// (starting from 0, which is the letter a).
+func AllBitsToNames(vector string, bits map[int]string, chars map[rune]string) ([]string, error) {
+	values := []string{}
+	if num, err := strconv.ParseInt(vector, 10, 64); err == nil {
+		// number-style bitvector
+		for n := uint(0); n < 64; n++ {
+			bit := int64(1) << n
+			if num&bit == 0 {
+				continue
+			}
+			name, ok := bits[int(bit)]
+			if !ok {
+				name = unknownBitName(n)
+			}
+			values = append(values, name)
+		}
+		return values, nil
+	}
+	for _, r := range []rune(vector) {
+		s, ok := chars[r]
+		if !ok {
+			n, ok := letterToBit(r)
+			if !ok {
+				return nil, fmt.Errorf("unknown bit vector letter: %v", r)
+			}
+			s = unknownBitName(n)
+		}
+		values = append(values, s)
+	}
+	return values, nil
+}
+
+// FlagsToNames converts tbaMUD style 128 bit flags, which are written as four
+// 32 bit words, into a list of bit names.  The names are indexed by bit number,
+// so bit 0 of the second word is names[32].
+func FlagsToNames(words []string, names []string) ([]string, error) {
+	values := []string{}
+	for w, word := range words {
+		nums, err := bitNumbers(word)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nums {
+			n += 32 * uint(w)
+			if n < uint(len(names)) && names[n] != "" {
+				values = append(values, names[n])
+			} else {
+				values = append(values, unknownBitName(n))
+			}
+		}
+	}
+	return values, nil
+}
+
+// NamesToFlags converts a list of bit names back into tbaMUD style 128 bit
+// flags, with each word written as letters.
+func NamesToFlags(values []string, names []string) ([]string, error) {
+	var words [4]uint32
+	for _, v := range values {
+		n, ok := parseUnknownBitName(v)
+		if !ok {
+			found := false
+			for i, name := range names {
+				if name == v {
+					n, found = uint(i), true
+					break
+				}
+			}
+			if !found {
+				return nil, fmt.Errorf("unknown bit name: %q", v)
+			}
+		}
+		if n >= 128 {
+			return nil, fmt.Errorf("bit %q is out of range", v)
+		}
+		words[n/32] |= 1 << (n % 32)
+	}
+	flags := make([]string, len(words))
+	for w, word := range words {
+		if word == 0 {
+			flags[w] = "0"
+			continue
+		}
+		var letters []rune
+		for n := uint(0); n < 32; n++ {
+			if word&(1<<n) != 0 {
+				letters = append(letters, bitToLetter(n))
+			}
+		}
+		flags[w] = string(letters)
+	}
+	return flags, nil
+}
+
+// bitNumbers returns the numbers of the bits set in a 32 bit word, which may
+// be written as a number or as letters.
+func bitNumbers(word string) ([]uint, error) {
+	var nums []uint
+	if num, err := strconv.ParseUint(word, 10, 32); err == nil {
+		for n := uint(0); n < 32; n++ {
+			if num&(1<<n) != 0 {
+				nums = append(nums, n)
+			}
+		}
+		return nums, nil
+	}
+	for _, r := range word {
+		n, ok := letterToBit(r)
+		if !ok || n >= 32 {
+			return nil, fmt.Errorf("unknown bit vector letter: %v", r)
+		}
+		nums = append(nums, n)
+	}
+	return nums, nil
+}
+
+// letterToBit converts a bitvector letter into its bit number.  The lowercase
+// letters are bits 0-25, and the uppercase letters continue from there.
+func letterToBit(r rune) (uint, bool) {
+	switch {
+	case r >= 'a' && r <= 'z':
+		return uint(r - 'a'), true
+	case r >= 'A' && r <= 'Z':
+		return uint(r-'A') + 26, true
+	}
+	return 0, false
+}
+
+// bitToLetter is the reverse of letterToBit.
+func bitToLetter(n uint) rune {
+	if n < 26 {
+		return 'a' + rune(n)
+	}
+	return 'A' + rune(n-26)
+}
+
+// unknownBitName is the name given to bit n when it has no other name.
+func unknownBitName(n uint) string {
+	return fmt.Sprintf("BIT_%d", n)
+}
+
+// parseUnknownBitName returns the number of the bit named by unknownBitName.
+func parseUnknownBitName(name string) (uint, bool) {
+	if !strings.HasPrefix(name, "BIT_") {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(name[len("BIT_"):], 10, 8)
+	if err != nil {
+		return 0, false
+	}
+	return uint(n), true
+}
+
+// NamesToBits converts a list of bit names back into a bitvector.  Letters are
+// used if every name has one, otherwise the bitvector is written as a number.
+func NamesToBits(names []string, bits map[int]string, chars map[rune]string) (string, error) {
+	if len(names) == 0 {
+		return "0", nil
+	}
+	// if more than one bit has a name, use the lowest, so that it's always
+	// written the same way.
+	letters := map[string]rune{}
+	for r, s := range chars {
+		if l, ok := letters[s]; ok {
+			a, _ := letterToBit(r)
+			b, _ := letterToBit(l)
+			if a > b {
+				continue
+			}
+		}
+		letters[s] = r
+	}
+	var runes []rune
+	for _, name := range names {
+		r, ok := letters[name]
+		if !ok {
+			n, ok := parseUnknownBitName(name)
+			if !ok || n >= 32 {
+				runes = nil
+				break
+			}
+			r = bitToLetter(n)
+		}
+		runes = append(runes, r)
+	}
+	if runes != nil {
+		sort.Slice(runes, func(i, j int) bool {
+			a, _ := letterToBit(runes[i])
+			b, _ := letterToBit(runes[j])
+			return a < b
+		})
+		return string(runes), nil
+	}
+
+	values := map[string]int64{}
+	for bit, s := range bits {
+		if v, ok := values[s]; !ok || int64(bit) < v {
+			values[s] = int64(bit)
+		}
+	}
+	var num int64
+	for _, name := range names {
+		bit, ok := values[name]
+		if !ok {
+			n, ok := parseUnknownBitName(name)
+			if !ok || n >= 64 {
+				return "", fmt.Errorf("unknown bit name: %q", name)
+			}
+			bit = int64(1) << n
+		}
+		num |= bit
+	}
+	return strconv.FormatInt(num, 10), nil
+}
@@ -0,0 +1,85 @@
+package lib
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnknownBits(t *testing.T) {
+	names, err := AllBitsToNames("65537", RoomBits, RoomChars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"DARK", "BIT_16"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	vector, err := NamesToBits(names, RoomBits, RoomChars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vector != "aq" {
+		t.Errorf("expected bitvector aq, got %q", vector)
+	}
+
+	names, err = AllBitsToNames("az", RoomBits, RoomChars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"DARK", "BIT_25"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestFlagsToNames(t *testing.T) {
+	words := []string{"dF", "0", "1", "0"}
+	names, err := FlagsToNames(words, TbaRoomFlags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"INDOORS", "BIT_31", "BIT_64"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+	flags, err := NamesToFlags(names, TbaRoomFlags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(flags, []string{"dF", "0", "a", "0"}) {
+		t.Errorf("unexpected flags: %v", flags)
+	}
+}
+
+func TestBitsToNamesMaxbit(t *testing.T) {
+	names, err := BitsToNames("65537", ROOM_BFS_MARK, RoomBits, RoomChars)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"DARK"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("expected %v, got %v", expected, names)
+	}
+}
+
+func TestNamesToBitsDuplicates(t *testing.T) {
+	bits := map[int]string{1: "DARK", 2: "UNUSED", 8: "UNUSED"}
+	chars := map[rune]string{'a': "DARK", 'b': "UNUSED", 'd': "UNUSED", 'A': "UNUSED"}
+	// map order is random, so try it a few times.
+	for i := 0; i < 20; i++ {
+		vector, err := NamesToBits([]string{"UNUSED"}, bits, chars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vector != "b" {
+			t.Fatalf("expected the lowest bit, b, got %q", vector)
+		}
+		vector, err = NamesToBits([]string{"UNUSED", "BIT_40"}, bits, chars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if vector != "1099511627778" {
+			t.Fatalf("expected the lowest bit and bit 40, got %q", vector)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// ColorMode says what to do with the color codes in text.
+//
+// Three kinds of color codes are understood: tbaMUD's @ codes and Easy Color's
+// & codes (@r, &R and so on, with @@ and && for a literal @ or &), and the
+// \c00 style codes of CircleMUD's color patch.
+type ColorMode int
+
+const (
+	// ColorKeep leaves color codes as they are.
+	ColorKeep ColorMode = iota
+	// ColorStrip removes color codes.
+	ColorStrip
+	// ColorANSI converts color codes to ANSI escape sequences.
+	ColorANSI
+	// ColorHTML converts color codes to <span> elements, with classes such as
+	// "fg-red", "bg-blue" and "bold", and escapes the text for HTML.
+	ColorHTML
+)
+
+// ColorModes holds the color modes by name.
+var ColorModes = map[string]ColorMode{
+	"keep":  ColorKeep,
+	"strip": ColorStrip,
+	"ansi":  ColorANSI,
+	"html":  ColorHTML,
+}
+
+// colorNames are the colors in ANSI order.
+var colorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// colorStyle is the color and attributes that text is shown with.
+type colorStyle struct {
+	fg, bg    string
+	bold      bool
+	blink     bool
+	underline bool
+	reverse   bool
+}
+
+// colorCode is a change to the colorStyle.
+type colorCode func(*colorStyle)
+
+// colorCodes holds the codes by how they're written.
+var colorCodes = map[string]colorCode{}
+
+func init() {
+	// tbaMUD and Easy Color use the same letters: lower case for colors,
+	// upper case for bright colors, and digits for backgrounds.
+	letters := map[byte]string{
+		'd': "black", 'r': "red", 'g': "green", 'y': "yellow",
+		'b': "blue", 'm': "magenta", 'c': "cyan", 'w': "white",
+	}
+	backgrounds := []string{"black", "blue", "green", "cyan", "red", "magenta", "yellow", "white"}
+	for _, prefix := range []string{"@", "&"} {
+		for c, color := range letters {
+			color := color
+			colorCodes[prefix+string(c)] = func(s *colorStyle) { s.fg, s.bold = color, false }
+			colorCodes[prefix+strings.ToUpper(string(c))] = func(s *colorStyle) { s.fg, s.bold = color, true }
+		}
+		for i, color := range backgrounds {
+			color := color
+			colorCodes[fmt.Sprintf("%s%d", prefix, i)] = func(s *colorStyle) { s.bg = color }
+		}
+		colorCodes[prefix+"n"] = func(s *colorStyle) { *s = colorStyle{} }
+		colorCodes[prefix+"o"] = func(s *colorStyle) { s.bold = true }
+		colorCodes[prefix+"l"] = func(s *colorStyle) { s.blink = true }
+		colorCodes[prefix+"u"] = func(s *colorStyle) { s.underline = true }
+		colorCodes[prefix+"e"] = func(s *colorStyle) { s.reverse = true }
+	}
+	// CircleMUD's color patch numbers the colors in ANSI order.
+	colorCodes[`\c00`] = func(s *colorStyle) { *s = colorStyle{} }
+	for i, color := range colorNames[1:] {
+		color := color
+		colorCodes[fmt.Sprintf(`\c%02d`, i+1)] = func(s *colorStyle) { s.fg = color }
+	}
+	colorCodes[`\c08`] = func(s *colorStyle) { s.bold = true }
+	colorCodes[`\c09`] = func(s *colorStyle) { s.blink = true }
+	colorCodes[`\c10`] = func(s *colorStyle) { s.underline = true }
+	colorCodes[`\c11`] = func(s *colorStyle) { s.reverse = true }
+}
+
+// RenderColors renders the color codes in s with the mode.  It also returns
+// any problems with the codes: codes it doesn't know, and colors that aren't
+// turned off again by the end of the text.  Unknown codes are left as text.
+// Text with problems is still rendered; for ANSI and HTML, colors that are
+// left on are turned off at the end.
+func RenderColors(s string, mode ColorMode) (string, []error) {
+	var errs []error
+	text := &bytes.Buffer{}
+	out := &bytes.Buffer{}
+	var style colorStyle
+	open := false // whether there's an open <span>
+	last := ""    // the last code that changed the style
+	writeText := func() {
+		if mode == ColorHTML {
+			out.WriteString(html.EscapeString(text.String()))
+		} else {
+			out.Write(text.Bytes())
+		}
+		text.Reset()
+	}
+	for i := 0; i < len(s); {
+		var prev byte
+		if i > 0 {
+			prev = s[i-1]
+		}
+		raw, ok := nextColorCode(s[i:], prev)
+		switch {
+		case raw == "":
+			text.WriteByte(s[i])
+			i++
+			continue
+		case raw == "@@" || raw == "&&":
+			if mode == ColorKeep {
+				text.WriteString(raw)
+			} else {
+				text.WriteByte(raw[0])
+			}
+			i += 2
+			continue
+		case !ok:
+			errs = append(errs, fmt.Errorf("unknown color code %q", raw))
+			text.WriteString(raw)
+			i += len(raw)
+			continue
+		}
+		i += len(raw)
+		colorCodes[raw](&style)
+		last = raw
+		writeText()
+		switch mode {
+		case ColorKeep:
+			out.WriteString(raw)
+		case ColorANSI:
+			out.WriteString(style.ansi())
+		case ColorHTML:
+			if open {
+				out.WriteString("</span>")
+			}
+			open = style != colorStyle{}
+			if open {
+				fmt.Fprintf(out, `<span class="%s">`, style.classes())
+			}
+		}
+	}
+	writeText()
+	if style != (colorStyle{}) {
+		errs = append(errs, fmt.Errorf("color code %q isn't turned off at the end", last))
+		if mode == ColorANSI {
+			out.WriteString(colorStyle{}.ansi())
+		}
+	}
+	if open {
+		out.WriteString("</span>")
+	}
+	return out.String(), errs
+}
+
+// nextColorCode returns the color code that s starts with, if any, and whether
+// it's one that's known.  Anything that doesn't look like an attempt at a color
+// code, such as an @ or & followed by a space, returns "".  prev is the byte
+// before s, or 0 at the start of the text.
+func nextColorCode(s string, prev byte) (string, bool) {
+	if len(s) < 2 {
+		return "", false
+	}
+	switch s[0] {
+	case '@':
+		c := s[1]
+		if c == '@' {
+			return s[:2], true
+		}
+		if c > ' ' && c < 0x7f {
+			_, ok := colorCodes[s[:2]]
+			return s[:2], ok
+		}
+	case '&':
+		// & is common in prose, so only the known codes count, and not in the
+		// middle of a word (Smith&Wesson).
+		if s[1] == '&' {
+			return s[:2], true
+		}
+		if _, ok := colorCodes[s[:2]]; !ok {
+			return "", false
+		}
+		if len(s) > 2 && isWordByte(prev) && isWordByte(s[2]) {
+			return "", false
+		}
+		return s[:2], true
+	case '\\':
+		if s[1] != 'c' {
+			return "", false
+		}
+		if len(s) >= 4 {
+			if _, ok := colorCodes[s[:4]]; ok {
+				return s[:4], true
+			}
+		}
+		return s[:2], false
+	}
+	return "", false
+}
+
+// isWordByte reports whether c is a letter or digit.
+func isWordByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// ansi returns the ANSI escape sequence that turns on the style.
+func (s colorStyle) ansi() string {
+	codes := []string{"0"}
+	if s.bold {
+		codes = append(codes, "1")
+	}
+	if s.underline {
+		codes = append(codes, "4")
+	}
+	if s.blink {
+		codes = append(codes, "5")
+	}
+	if s.reverse {
+		codes = append(codes, "7")
+	}
+	for i, color := range colorNames {
+		if s.fg == color {
+			codes = append(codes, fmt.Sprintf("3%d", i))
+		}
+		if s.bg == color {
+			codes = append(codes, fmt.Sprintf("4%d", i))
+		}
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// classes returns the HTML classes for the style.
+func (s colorStyle) classes() string {
+	var classes []string
+	if s.fg != "" {
+		classes = append(classes, "fg-"+s.fg)
+	}
+	if s.bg != "" {
+		classes = append(classes, "bg-"+s.bg)
+	}
+	if s.bold {
+		classes = append(classes, "bold")
+	}
+	if s.underline {
+		classes = append(classes, "underline")
+	}
+	if s.blink {
+		classes = append(classes, "blink")
+	}
+	if s.reverse {
+		classes = append(classes, "reverse")
+	}
+	return strings.Join(classes, " ")
+}
+
+// colorField is a piece of text in a record that may have color codes in it.
+type colorField struct {
+	name string
+	text *string
+}
+
+// renderColors renders the color codes in the fields with opts.Color, and
+// passes any problems with them to opts.Warn, saying they're from what.
+func (opts Options) renderColors(what string, fields ...colorField) {
+	if opts.Color == ColorKeep && opts.Warn == nil {
+		return
+	}
+	for _, f := range fields {
+		s, errs := RenderColors(*f.text, opts.Color)
+		*f.text = s
+		if opts.Warn == nil {
+			continue
+		}
+		for _, err := range errs {
+			opts.Warn(fmt.Errorf("%s %s: %v", what, f.name, err))
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package lib
+
+import (
+	"testing"
+)
+
+func TestRenderColors(t *testing.T) {
+	const s = `@RRed@n &gg&&\c04b\c00 <`
+	tests := []struct {
+		mode     ColorMode
+		expected string
+	}{
+		{ColorKeep, s},
+		{ColorStrip, "Red g&b <"},
+		{ColorANSI, "\x1b[0;1;31mRed\x1b[0m \x1b[0;32mg&\x1b[0;34mb\x1b[0m <"},
+		{ColorHTML, `<span class="fg-red bold">Red</span> <span class="fg-green">g&amp;</span><span class="fg-blue">b</span> &lt;`},
+	}
+	for _, test := range tests {
+		out, errs := RenderColors(s, test.mode)
+		if len(errs) > 0 {
+			t.Errorf("mode %d: unexpected errors: %v", test.mode, errs)
+		}
+		if out != test.expected {
+			t.Errorf("mode %d: expected %q, got %q", test.mode, test.expected, out)
+		}
+	}
+}
+
+func TestRenderColorsErrors(t *testing.T) {
+	out, errs := RenderColors("@xa @Yb & c", ColorANSI)
+	if out != "@xa \x1b[0;1;33mb & c\x1b[0m" {
+		t.Errorf("unexpected output: %q", out)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	if errs[0].Error() != `unknown color code "@x"` {
+		t.Errorf("unexpected error: %v", errs[0])
+	}
+	if errs[1].Error() != `color code "@Y" isn't turned off at the end` {
+		t.Errorf("unexpected error: %v", errs[1])
+	}
+}
+
+func TestRenderColorsProse(t *testing.T) {
+	const s = "Smith&Wesson, AT&T and R & D. &Rred&n"
+	out, errs := RenderColors(s, ColorStrip)
+	if len(errs) > 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	if out != "Smith&Wesson, AT&T and R & D. red" {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
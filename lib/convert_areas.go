@@ -0,0 +1,778 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConvertAreas converts all the Merc and ROM area files in the from directory
+// that match the pattern to json files in the to directory.
+func ConvertAreas(to, from, pattern string) error {
+	files, err := filepath.Glob(filepath.Join(from, pattern))
+	if err != nil {
+		return err
+	}
+	return ConvertAreaFiles(to, files, Options{})
+}
+
+// ConvertAreaFiles converts the given Merc and ROM area files to json files in
+// the to directory.  The rooms and mobs are converted to the same json as
+// CircleMUD's, so they can be converted back into .wld and .mob files with
+// ConvertJSONFiles.
+func ConvertAreaFiles(to string, files []string, opts Options) error {
+	if err := makeOutputDir(to); err != nil {
+		return err
+	}
+	for _, name := range files {
+		f, n, _, err := readInput(name, opts.Encoding)
+		if err != nil {
+			return err
+		}
+		area, err := ParseArea(f, n)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(area, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to convert %q to json: %v", name, err)
+		}
+		if err := writeOutput(to, name, ".json", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Area is the contents of a Merc or ROM area file, converted to CircleMUD's
+// records.
+type Area struct {
+	Zone  Zone   `json:"zone"`
+	Rooms []Room `json:"rooms"`
+	Mobs  []*Mob `json:"mobs"`
+	// Unconverted lists the data from the area file that was left out,
+	// because CircleMUD has no equivalent or because the section isn't
+	// converted (such as objects and resets).
+	Unconverted []Unconverted `json:"unconverted"`
+}
+
+// Unconverted is a piece of data from an area file that was left out of the
+// converted records.
+type Unconverted struct {
+	// Record is "area", "room" or "mob".
+	Record string `json:"record"`
+	Number int    `json:"number"`
+	Field  string `json:"field"`
+	Value  string `json:"value"`
+}
+
+// areaScanner is a fileScanner that keeps track of what couldn't be
+// converted.
+type areaScanner struct {
+	*fileScanner
+	area *Area
+}
+
+func (s *areaScanner) unconverted(record string, number int, field, value string) {
+	s.area.Unconverted = append(s.area.Unconverted, Unconverted{
+		Record: record,
+		Number: number,
+		Field:  field,
+		Value:  value,
+	})
+}
+
+// ParseAreFile parses the given Merc or ROM 2.4 area file.  The #AREA, #ROOMS
+// and #MOBILES sections are converted, and the other sections are listed in
+// the area's Unconverted data.
+func ParseAreFile(filename string) (*Area, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseArea(f, filename)
+}
+
+// ParseArea parses a Merc or ROM area file read from r.  The name is used in
+// error messages.
+func ParseArea(r io.Reader, name string) (area *Area, err error) {
+	// need this because scan can panic if you send it too much stuff
+	defer func() {
+		panicErr := recover()
+		if panicErr == nil {
+			return
+		}
+		if e, ok := panicErr.(error); ok {
+			err = e
+			return
+		}
+		err = fmt.Errorf("%v", panicErr)
+	}()
+
+	line := 0
+	scanner := &areaScanner{
+		fileScanner: &fileScanner{
+			line:    &line,
+			Scanner: bufio.NewScanner(r),
+		},
+		area: &Area{},
+	}
+	defer func() {
+		if err != nil {
+			// add where in the file the error happened
+			err = scanner.parseError(name, err)
+		}
+	}()
+	if err := scanAreaSections(scanner); err != nil {
+		return nil, err
+	}
+	area = scanner.area
+	if area.Zone.BottomNumber == 0 && area.Zone.TopNumber == 0 {
+		// Merc's area header doesn't have the vnums, so use the rooms'.
+		for i, r := range area.Rooms {
+			if i == 0 || r.Number < area.Zone.BottomNumber {
+				area.Zone.BottomNumber = r.Number
+			}
+			if r.Number > area.Zone.TopNumber {
+				area.Zone.TopNumber = r.Number
+			}
+		}
+	}
+	area.Zone.Number = area.Zone.BottomNumber / 100
+	for i := range area.Rooms {
+		area.Rooms[i].Zone = area.Zone.Number
+	}
+	for i := range area.Unconverted {
+		if area.Unconverted[i].Record == "area" {
+			area.Unconverted[i].Number = area.Zone.Number
+		}
+	}
+	return area, nil
+}
+
+func scanAreaSections(scanner *areaScanner) error {
+	for {
+		if !scanner.Scan() {
+			if err := scanner.Err(); err != nil {
+				return err
+			}
+			// end of file without #$, that's ok.
+			return nil
+		}
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" {
+			continue
+		}
+		fields := strings.Fields(s)
+		switch fields[0] {
+		case "#$":
+			return nil
+		case "#AREA":
+			if err := scanAreaHeader(scanner); err != nil {
+				return err
+			}
+		case "#ROOMS":
+			for {
+				if err := scanner.MustScan(); err != nil {
+					return err
+				}
+				if strings.TrimSpace(scanner.Text()) == "#0" {
+					break
+				}
+				room, err := scanRomRoom(scanner)
+				if err != nil {
+					return err
+				}
+				scanner.area.Rooms = append(scanner.area.Rooms, *room)
+			}
+		case "#MOBILES":
+			if err := scanner.MustScan(); err != nil {
+				return err
+			}
+			for strings.TrimSpace(scanner.Text()) != "#0" {
+				mob, err := scanRomMob(scanner)
+				if err != nil {
+					return err
+				}
+				scanner.area.Mobs = append(scanner.area.Mobs, mob)
+			}
+		case "#OBJECTS", "#MOBPROGS", "#SOCIALS":
+			if err := skipAreaSection(scanner, "#0"); err != nil {
+				return err
+			}
+			scanner.unconverted("area", 0, "section", fields[0])
+		case "#RESETS", "#SPECIALS":
+			if err := skipAreaSection(scanner, "S"); err != nil {
+				return err
+			}
+			scanner.unconverted("area", 0, "section", fields[0])
+		case "#SHOPS":
+			if err := skipAreaSection(scanner, "0"); err != nil {
+				return err
+			}
+			scanner.unconverted("area", 0, "section", fields[0])
+		case "#HELPS":
+			if err := skipAreaHelps(scanner); err != nil {
+				return err
+			}
+			scanner.unconverted("area", 0, "section", fields[0])
+		default:
+			return fmt.Errorf("unknown area file section: %q", s)
+		}
+	}
+}
+
+// scanAreaHeader parses the #AREA section.  ROM's is four lines:
+// <filename>~, <name>~, <credits>~ and <min_vnum> <max_vnum>.  Merc's is just
+// the area name on the #AREA line.
+func scanAreaHeader(scanner *areaScanner) error {
+	z := &scanner.area.Zone
+	// ROM resets areas whether or not there are players in them.  The timing
+	// isn't in the file, so use CircleMUD's default lifespan.
+	z.LifespanMins = 30
+	z.ResetMode = RESET_ALWAYS
+
+	s := strings.TrimSpace(scanner.Text())
+	if rest := strings.TrimSpace(strings.TrimPrefix(s, "#AREA")); rest != "" {
+		if !strings.HasSuffix(rest, "~") {
+			return expectedError("area name to end with ~", rest)
+		}
+		z.Name = strings.TrimSpace(rest[:len(rest)-1])
+		return nil
+	}
+	if _, err := scanner.ScanUntil("~"); err != nil {
+		return err
+	}
+	name, err := scanner.ScanUntil("~")
+	if err != nil {
+		return err
+	}
+	z.Name = name
+	credits, err := scanner.ScanUntil("~")
+	if err != nil {
+		return err
+	}
+	if credits != "" {
+		scanner.unconverted("area", 0, "credits", credits)
+	}
+	if err := scanner.MustScan(); err != nil {
+		return err
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) != 2 {
+		return expectedError("area vnums to be <min_vnum> <max_vnum>", scanner.Text())
+	}
+	if z.BottomNumber, err = strconv.Atoi(fields[0]); err != nil {
+		return fmt.Errorf("invalid min vnum: %q", fields[0])
+	}
+	if z.TopNumber, err = strconv.Atoi(fields[1]); err != nil {
+		return fmt.Errorf("invalid max vnum: %q", fields[1])
+	}
+	return nil
+}
+
+// skipAreaSection skips over a section that isn't converted, up to the line
+// that ends it.
+func skipAreaSection(scanner *areaScanner, end string) error {
+	for {
+		if err := scanner.MustScan(); err != nil {
+			return err
+		}
+		if strings.TrimSpace(scanner.Text()) == end {
+			return nil
+		}
+	}
+}
+
+// skipAreaHelps skips over a #HELPS section, which is a list of
+// <level> <keywords>~ and <text>~ entries, ending with an entry whose keywords
+// are $.
+func skipAreaHelps(scanner *areaScanner) error {
+	for {
+		if err := scanner.MustScan(); err != nil {
+			return err
+		}
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" {
+			continue
+		}
+		fields := strings.SplitN(s, " ", 2)
+		if len(fields) == 2 && strings.HasPrefix(strings.TrimSpace(fields[1]), "$") {
+			return nil
+		}
+		if !strings.HasSuffix(s, "~") {
+			if _, err := scanner.ScanUntil("~"); err != nil {
+				return err
+			}
+		}
+		if _, err := scanner.ScanUntil("~"); err != nil {
+			return err
+		}
+	}
+}
+
+func scanRomRoom(scanner *areaScanner) (*Room, error) {
+	scanner.endRecord()
+	number := strings.TrimSpace(scanner.Text())
+	if !strings.HasPrefix(number, "#") {
+		return nil, expectedError("room number to start with #", number)
+	}
+	num, err := strconv.Atoi(number[1:])
+	if err != nil {
+		return nil, fmt.Errorf("room number %q not a number: %v", number[1:], err)
+	}
+	r := Room{Number: num}
+	scanner.startRecord(num)
+	name, err := scanner.ScanUntil("~")
+	if err != nil {
+		return nil, err
+	}
+	r.Name = name
+	desc, err := scanner.ScanUntil("~")
+	if err != nil {
+		return nil, err
+	}
+	r.Description = desc
+	if err := scanner.MustScan(); err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) != 3 {
+		return nil, expectedError("room metadata to be <area#> <room_flags> <sector>", scanner.Text())
+	}
+	flags, err := RomFlagsToNames(fields[1], RomRoomFlags)
+	if err != nil {
+		return nil, err
+	}
+	bits, rom := romToCircle(flags, romRoomFlagsToCircle)
+	r.Bits = bits
+	if len(rom) > 0 {
+		scanner.unconverted("room", num, "room_flags", strings.Join(rom, " "))
+	}
+	sector, ok := RomSectors[fields[2]]
+	if !ok {
+		return nil, fmt.Errorf("unknown room sector type: %q", fields[2])
+	}
+	if s, ok := romSectorsToCircle[sector]; ok {
+		r.Sector = s
+	} else {
+		// keep the ROM sector, so it isn't lost.
+		r.Sector = romSectorsNearest[sector]
+		r.SectorRaw = sector
+	}
+	for {
+		if err := scanner.MustScan(); err != nil {
+			return nil, err
+		}
+		s := strings.TrimSpace(scanner.Text())
+		switch {
+		case s == "":
+			continue
+		case s == "S":
+			return &r, nil
+		case strings.HasPrefix(s, "D"):
+			dir, err := scanRomExit(scanner, num)
+			if err != nil {
+				return nil, err
+			}
+			r.Exits = append(r.Exits, *dir)
+		case s == "E":
+			ex, err := scanExtra(scanner.fileScanner)
+			if err != nil {
+				return nil, err
+			}
+			r.Extras = append(r.Extras, *ex)
+		case strings.HasPrefix(s, "H"), strings.HasPrefix(s, "M"):
+			// heal and mana rates, which may share a line: H 100 M 100
+			fields := strings.Fields(s)
+			if len(fields)%2 != 0 {
+				return nil, expectedError("room rates to be H <heal rate> M <mana rate>", s)
+			}
+			for i := 0; i < len(fields); i += 2 {
+				var field string
+				switch fields[i] {
+				case "H":
+					field = "heal_rate"
+				case "M":
+					field = "mana_rate"
+				default:
+					return nil, fmt.Errorf("unexpected token in room definition: %q", s)
+				}
+				if fields[i+1] != "100" {
+					scanner.unconverted("room", num, field, fields[i+1])
+				}
+			}
+		case strings.HasPrefix(s, "C"), strings.HasPrefix(s, "O"):
+			field := "clan"
+			if s[0] == 'O' {
+				field = "owner"
+			}
+			val := strings.TrimSpace(s[1:])
+			if !strings.HasSuffix(val, "~") {
+				rest, err := scanner.ScanUntil("~")
+				if err != nil {
+					return nil, err
+				}
+				val = strings.TrimSpace(val + "\n" + rest)
+			} else {
+				val = strings.TrimSpace(val[:len(val)-1])
+			}
+			if val != "" {
+				scanner.unconverted("room", num, field, val)
+			}
+		default:
+			return nil, fmt.Errorf("unexpected token in room definition: %q", s)
+		}
+	}
+}
+
+func scanRomExit(scanner *areaScanner, room int) (*Exit, error) {
+	// previous code checked that the first character was a D so we can ignore that.
+	s := strings.TrimSpace(scanner.Text()[1:])
+	dir, ok := ExitDir[s]
+	if !ok {
+		return nil, fmt.Errorf("unknown exit direction %q", s)
+	}
+	ex := &Exit{Direction: dir, Opposite: OppositeDir[dir]}
+	desc, err := scanner.ScanUntil("~")
+	if err != nil {
+		return nil, err
+	}
+	ex.Description = desc
+	keywords, err := scanner.ScanUntil("~")
+	if err != nil {
+		return nil, err
+	}
+	ex.Keywords = strings.Fields(keywords)
+	if err := scanner.MustScan(); err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) != 3 {
+		return nil, expectedError("direction fields to be <locks> <key_number> <room_linked>", scanner.Text())
+	}
+	// ROM's locks: 0 none, 1 door, 2 pickproof, 3 door and nopass, 4 all three.
+	switch fields[0] {
+	case "0":
+		ex.DoorFlag = DoorFlags["0"]
+	case "1", "3":
+		ex.DoorFlag = DoorFlags["1"]
+	case "2", "4":
+		ex.DoorFlag = DoorFlags["2"]
+	default:
+		return nil, fmt.Errorf("unknown door locks %q", fields[0])
+	}
+	if fields[0] == "3" || fields[0] == "4" {
+		scanner.unconverted("room", room, "exit_flags", dir+" NOPASS")
+	}
+	num, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid key number: %q", fields[1])
+	}
+	ex.KeyNumber = num
+	dest, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid target room number: %q", fields[2])
+	}
+	ex.Destination = dest
+	return ex, nil
+}
+
+// scanRomMob parses a ROM 2.4 mob, or an old style (Merc) mob, which has no
+// race line and is laid out like a CircleMUD S type mob.
+func scanRomMob(scanner *areaScanner) (*Mob, error) {
+	scanner.endRecord()
+	number := strings.TrimSpace(scanner.Text())
+	if !strings.HasPrefix(number, "#") {
+		return nil, expectedError("mob number to start with #", number)
+	}
+	num, err := strconv.Atoi(number[1:])
+	if err != nil {
+		return nil, fmt.Errorf("mob number %q not a number: %v", number[1:], err)
+	}
+	m := Mob{Number: num}
+	scanner.startRecord(num)
+
+	d, err := scanner.ScanUntil("~")
+	if err != nil {
+		return nil, err
+	}
+	m.Aliases = strings.Split(d, " ")
+	if m.ShortDesc, err = scanner.ScanUntil("~"); err != nil {
+		return nil, err
+	}
+	if m.LongDesc, err = scanner.ScanUntil("~"); err != nil {
+		return nil, err
+	}
+	if m.DetailedDesc, err = scanner.ScanUntil("~"); err != nil {
+		return nil, err
+	}
+	if err := scanner.MustScan(); err != nil {
+		return nil, err
+	}
+	if s := strings.TrimSpace(scanner.Text()); strings.HasSuffix(s, "~") {
+		if race := strings.TrimSpace(s[:len(s)-1]); race != "" {
+			scanner.unconverted("mob", num, "race", race)
+		}
+		if err := scanner.MustScan(); err != nil {
+			return nil, err
+		}
+		err = scanRomMobStats(scanner, &m)
+	} else {
+		err = scanMercMobStats(scanner, &m)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		if err := scanner.MustScan(); err != nil {
+			return nil, err
+		}
+		s := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(s, "#"):
+			return &m, nil
+		case s == "":
+			continue
+		case strings.HasPrefix(s, "F "):
+			// flags removed from the race's defaults
+			scanner.unconverted("mob", num, "remove_flags", strings.TrimSpace(s[2:]))
+		case strings.HasPrefix(s, "M "):
+			scanner.unconverted("mob", num, "mprog", strings.TrimSpace(s[2:]))
+		default:
+			return nil, fmt.Errorf("unexpected token in mob definition: %q", s)
+		}
+	}
+}
+
+// scanRomMobFlags converts a mob's act and affected_by flags, starting from
+// the current line.
+func scanRomMobFlags(scanner *areaScanner, m *Mob, act, affected string) error {
+	names, err := RomFlagsToNames(act, RomActFlags)
+	if err != nil {
+		return err
+	}
+	actions, rom := romToCircle(names, romActFlagsToCircle)
+	m.Actions = actions
+	var left []string
+	for _, name := range rom {
+		// every mob is an NPC in CircleMUD
+		if name != "IS_NPC" {
+			left = append(left, name)
+		}
+	}
+	if len(left) > 0 {
+		scanner.unconverted("mob", m.Number, "act", strings.Join(left, " "))
+	}
+	names, err = RomFlagsToNames(affected, RomAffectFlags)
+	if err != nil {
+		return err
+	}
+	affections, rom := romToCircle(names, romAffectFlagsToCircle)
+	m.Affections = affections
+	if len(rom) > 0 {
+		scanner.unconverted("mob", m.Number, "affected_by", strings.Join(rom, " "))
+	}
+	return nil
+}
+
+// scanRomMobStats parses the lines of a ROM 2.4 mob after the race, starting
+// from the current line.
+func scanRomMobStats(scanner *areaScanner, m *Mob) error {
+	num := m.Number
+	fields := strings.Fields(scanner.Text())
+	if len(fields) != 4 {
+		return expectedError("mob metadata to be <act> <affected_by> <alignment> <group>", scanner.Text())
+	}
+	if err := scanRomMobFlags(scanner, m, fields[0], fields[1]); err != nil {
+		return err
+	}
+	alignment, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return err
+	}
+	m.Alignment = alignment
+	if fields[3] != "0" {
+		scanner.unconverted("mob", num, "group", fields[3])
+	}
+
+	if err := scanner.MustScan(); err != nil {
+		return err
+	}
+	fields = strings.Fields(scanner.Text())
+	if len(fields) != 6 {
+		return expectedError("mob metadata to be <level> <hitroll> <hit dice> <mana dice> <damage dice> <damage type>", scanner.Text())
+	}
+	if m.Level, err = strconv.Atoi(fields[0]); err != nil {
+		return fmt.Errorf("invalid level: %v", err)
+	}
+	hitroll, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return fmt.Errorf("invalid hitroll: %v", err)
+	}
+	// CircleMUD reads the hitroll as 20 - thac0
+	m.THAC0 = 20 - hitroll
+	m.HP = fields[2]
+	if fields[3] != "0d0+0" {
+		scanner.unconverted("mob", num, "mana", fields[3])
+	}
+	m.Damage = fields[4]
+	if attack, err := reverseLookup(AttackTypes, fields[5], "attack type"); err != nil {
+		scanner.unconverted("mob", num, "dam_type", fields[5])
+	} else if attack != "0" {
+		m.BareHandAttack = fields[5]
+	}
+
+	if err := scanner.MustScan(); err != nil {
+		return err
+	}
+	fields = strings.Fields(scanner.Text())
+	if len(fields) != 4 {
+		return expectedError("mob armor to be <pierce> <bash> <slash> <exotic>", scanner.Text())
+	}
+	if m.AC, err = strconv.Atoi(fields[0]); err != nil {
+		return fmt.Errorf("invalid AC: %v", err)
+	}
+	if fields[1] != fields[0] || fields[2] != fields[0] || fields[3] != fields[0] {
+		scanner.unconverted("mob", num, "ac", fmt.Sprintf("bash %s slash %s exotic %s", fields[1], fields[2], fields[3]))
+	}
+
+	if err := scanner.MustScan(); err != nil {
+		return err
+	}
+	fields = strings.Fields(scanner.Text())
+	if len(fields) != 4 {
+		return expectedError("mob metadata to be <offensive> <immune> <resistant> <vulnerable>", scanner.Text())
+	}
+	for i, field := range []string{"off_flags", "imm_flags", "res_flags", "vuln_flags"} {
+		if fields[i] != "0" {
+			scanner.unconverted("mob", num, field, fields[i])
+		}
+	}
+
+	if err := scanner.MustScan(); err != nil {
+		return err
+	}
+	fields = strings.Fields(scanner.Text())
+	if len(fields) != 4 {
+		return expectedError("mob metadata to be <start position> <default position> <sex> <wealth>", scanner.Text())
+	}
+	pos, ok := RomPositions[fields[0]]
+	if !ok {
+		return fmt.Errorf("unknown position: %s", fields[0])
+	}
+	m.LoadPosition = pos
+	if pos, ok = RomPositions[fields[1]]; !ok {
+		return fmt.Errorf("unknown position: %s", fields[1])
+	}
+	m.DefaultPosition = pos
+	switch gender, ok := RomSexes[fields[2]]; {
+	case ok:
+		m.Gender = gender
+	case fields[2] == "either":
+		m.Gender = RomSexes["none"]
+		scanner.unconverted("mob", num, "sex", fields[2])
+	default:
+		return fmt.Errorf("unknown sex: %s", fields[2])
+	}
+	if m.Gold, err = strconv.Atoi(fields[3]); err != nil {
+		return fmt.Errorf("invalid wealth: %v", err)
+	}
+
+	if err := scanner.MustScan(); err != nil {
+		return err
+	}
+	fields = strings.Fields(scanner.Text())
+	if len(fields) != 4 {
+		return expectedError("mob metadata to be <form> <parts> <size> <material>", scanner.Text())
+	}
+	for i, field := range []string{"form", "parts", "size", "material"} {
+		if fields[i] != "0" {
+			scanner.unconverted("mob", num, field, fields[i])
+		}
+	}
+	return nil
+}
+
+// scanMercMobStats parses the lines of an old style mob after the
+// description, starting from the current line.
+func scanMercMobStats(scanner *areaScanner, m *Mob) error {
+	fields := strings.Fields(scanner.Text())
+	if len(fields) != 4 {
+		return expectedError("mob metadata to be <act> <affected_by> <alignment> S", scanner.Text())
+	}
+	if err := scanRomMobFlags(scanner, m, fields[0], fields[1]); err != nil {
+		return err
+	}
+	alignment, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return err
+	}
+	m.Alignment = alignment
+
+	if err := scanner.MustScan(); err != nil {
+		return err
+	}
+	fields = strings.Fields(scanner.Text())
+	if len(fields) != 5 {
+		return expectedError("mob metadata to be <level> <hitroll> <armor class> <hit dice> <damage dice>", scanner.Text())
+	}
+	if m.Level, err = strconv.Atoi(fields[0]); err != nil {
+		return fmt.Errorf("invalid level: %v", err)
+	}
+	hitroll, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return fmt.Errorf("invalid hitroll: %v", err)
+	}
+	m.THAC0 = 20 - hitroll
+	if m.AC, err = strconv.Atoi(fields[2]); err != nil {
+		return fmt.Errorf("invalid AC: %v", err)
+	}
+	m.HP = fields[3]
+	m.Damage = fields[4]
+
+	if err := scanner.MustScan(); err != nil {
+		return err
+	}
+	fields = strings.Fields(scanner.Text())
+	if len(fields) != 2 {
+		return expectedError("mob metadata to be <gold> <experience points>", scanner.Text())
+	}
+	if m.Gold, err = strconv.Atoi(fields[0]); err != nil {
+		return err
+	}
+	if m.XP, err = strconv.Atoi(fields[1]); err != nil {
+		return err
+	}
+
+	if err := scanner.MustScan(); err != nil {
+		return err
+	}
+	fields = strings.Fields(scanner.Text())
+	if len(fields) != 3 {
+		return expectedError("mob metadata to be <load position> <default position> <sex>", scanner.Text())
+	}
+	pos, ok := PositionNames[fields[0]]
+	if !ok {
+		return fmt.Errorf("unknown position: %s", fields[0])
+	}
+	m.LoadPosition = pos
+	if pos, ok = PositionNames[fields[1]]; !ok {
+		return fmt.Errorf("unknown position: %s", fields[1])
+	}
+	m.DefaultPosition = pos
+	gender, ok := genders[fields[2]]
+	if !ok {
+		return fmt.Errorf("unknown gender: %s", fields[2])
+	}
+	m.Gender = gender
+	return nil
+}
@@ -0,0 +1,228 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConvertHelp converts all the CircleMUD help files in the from directory
+// that match the pattern to json files in the to directory.
+func ConvertHelp(to, from, pattern string) error {
+	files, err := filepath.Glob(filepath.Join(from, pattern))
+	if err != nil {
+		return err
+	}
+	return ConvertHelpFiles(to, files, Options{})
+}
+
+// ConvertHelpFiles converts the given CircleMUD help files to json files in the
+// to directory.  Each output file lists the keywords from that file which are
+// also used by another entry, in any of the given files.
+func ConvertHelpFiles(to string, files []string, opts Options) error {
+	if err := makeOutputDir(to); err != nil {
+		return err
+	}
+	parsed := make([][]*HelpEntry, len(files))
+	var all []*HelpEntry
+	for i, name := range files {
+		f, n, _, err := readInput(name, opts.Encoding)
+		if err != nil {
+			return err
+		}
+		entries, err := ParseHelp(f, n)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		parsed[i] = entries
+		all = append(all, entries...)
+	}
+	dupes := DuplicateHelpKeywords(all)
+	for i, name := range files {
+		fileDupes := map[string][]HelpLocation{}
+		for _, e := range parsed[i] {
+			for _, k := range e.Keywords {
+				if locs, ok := dupes[strings.ToUpper(k)]; ok {
+					fileDupes[strings.ToUpper(k)] = locs
+				}
+			}
+		}
+		output := struct {
+			Help       []*HelpEntry              `json:"help"`
+			Duplicates map[string][]HelpLocation `json:"duplicate_keywords"`
+		}{
+			Help:       parsed[i],
+			Duplicates: fileDupes,
+		}
+		b, err := json.MarshalIndent(output, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to convert %q to json: %v", name, err)
+		}
+		if err := writeOutput(to, name, ".json", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HelpEntry is a single topic from the on-line help.
+type HelpEntry struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line"`
+	Keywords []string `json:"keywords"`
+	Level    int      `json:"min_level"`
+	Body     string   `json:"body"`
+}
+
+// HelpLocation is where a help entry was defined.
+type HelpLocation struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+}
+
+// DuplicateHelpKeywords returns the keywords (uppercased, since keywords are
+// not case sensitive) that are used by more than one of the given entries,
+// with where each of those entries was defined.
+func DuplicateHelpKeywords(entries []*HelpEntry) map[string][]HelpLocation {
+	locs := map[string][]HelpLocation{}
+	for _, e := range entries {
+		seen := map[string]bool{}
+		for _, k := range e.Keywords {
+			k = strings.ToUpper(k)
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			locs[k] = append(locs[k], HelpLocation{File: e.File, Line: e.Line})
+		}
+	}
+	for k, l := range locs {
+		if len(l) < 2 {
+			delete(locs, k)
+		}
+	}
+	return locs
+}
+
+// ParseHelpFile parses the given CircleMUD help file.
+func ParseHelpFile(filename string) ([]*HelpEntry, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseHelp(f, filename)
+}
+
+// ParseHelp parses help entries from a hlp file read from r.  The name is used
+// in error messages.
+func ParseHelp(r io.Reader, name string) (_ []*HelpEntry, err error) {
+	// need this because scan can panic if you send it too much stuff
+	defer func() {
+		panicErr := recover()
+		if panicErr == nil {
+			return
+		}
+		if e, ok := panicErr.(error); ok {
+			err = e
+			return
+		}
+		err = fmt.Errorf("%v", panicErr)
+	}()
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	defer func() {
+		if err != nil {
+			// add where in the file the error happened
+			err = scanner.parseError(name, err)
+		}
+	}()
+	entries := []*HelpEntry{}
+	for {
+		if !scanner.Scan() {
+			if err = scanner.Err(); err != nil {
+				return nil, err
+			}
+			// the file is supposed to end with $, but be lenient like with rooms.
+			return entries, nil
+		}
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" {
+			continue
+		}
+		// stock help files end with $~, not just $.
+		if strings.HasPrefix(s, "$") {
+			return entries, nil
+		}
+		entry, err := scanHelpEntry(scanner)
+		if err != nil {
+			return nil, err
+		}
+		entry.File = name
+		entries = append(entries, entry)
+	}
+}
+
+func scanHelpEntry(scanner *fileScanner) (*HelpEntry, error) {
+	h := HelpEntry{
+		Line:     *scanner.line,
+		Keywords: splitKeywords(scanner.Text()),
+	}
+	var lines []string
+	for {
+		if err := scanner.MustScan(); err != nil {
+			return nil, err
+		}
+		s := scanner.Text()
+		if strings.HasPrefix(s, "#") {
+			lvl := strings.TrimSpace(s[1:])
+			if lvl != "" {
+				level, err := strconv.Atoi(lvl)
+				if err != nil {
+					return nil, fmt.Errorf("invalid help entry level: %q", lvl)
+				}
+				h.Level = level
+			}
+			h.Body = strings.Join(lines, "\n")
+			return &h, nil
+		}
+		lines = append(lines, s)
+	}
+}
+
+// splitKeywords splits a help keyword line into keywords.  Keywords are
+// separated by whitespace, but may be put in double quotes to include spaces.
+func splitKeywords(s string) []string {
+	var keywords []string
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return keywords
+		}
+		if s[0] == '"' {
+			end := strings.IndexByte(s[1:], '"')
+			if end == -1 {
+				return append(keywords, s[1:])
+			}
+			keywords = append(keywords, s[1:end+1])
+			s = s[end+2:]
+			continue
+		}
+		end := strings.IndexAny(s, " \t")
+		if end == -1 {
+			return append(keywords, s)
+		}
+		keywords = append(keywords, s[:end])
+		s = s[end:]
+	}
+}
@@ -0,0 +1,107 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// ConvertJSON converts all the json files in the from directory that match
+// the pattern (as written by the other Convert functions) back into CircleMUD
+// files in the to directory.
+func ConvertJSON(to, from, pattern string) error {
+	files, err := filepath.Glob(filepath.Join(from, pattern))
+	if err != nil {
+		return err
+	}
+	return ConvertJSONFiles(to, files, Options{})
+}
+
+// ConvertJSONFiles converts the given json files back into CircleMUD files in
+// the to directory.  What kind of file is written depends on what the json
+// contains: rooms are written as .wld files, and mobs as .mob files.  Json
+// with both (such as a converted area file) is written as both.  Files are
+// written with \r\n line endings if the json's line_ending is "crlf", and in
+// opts.Encoding, or the json's encoding if that is nil.  Likewise, they're
+// written with opts.Dialect, or the dialect recorded in the json.
+func ConvertJSONFiles(to string, files []string, opts Options) error {
+	if err := makeOutputDir(to); err != nil {
+		return err
+	}
+	for _, name := range files {
+		f, n, err := openInput(name)
+		if err != nil {
+			return err
+		}
+		b, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		var input struct {
+			Rooms      []Room `json:"rooms"`
+			Mobs       []*Mob `json:"mobs"`
+			LineEnding string `json:"line_ending"`
+			Encoding   string `json:"encoding"`
+			Dialect    string `json:"dialect"`
+		}
+		if err := json.Unmarshal(b, &input); err != nil {
+			return fmt.Errorf("failed to read %q: %v", n, err)
+		}
+		if input.Rooms == nil && input.Mobs == nil {
+			return fmt.Errorf("%q doesn't contain anything that can be converted", n)
+		}
+		d := opts.Dialect
+		if d == nil && input.Dialect != "" {
+			var ok bool
+			if d, ok = Dialects[input.Dialect]; !ok {
+				return fmt.Errorf("%q has unknown dialect %q", n, input.Dialect)
+			}
+		}
+		enc := opts.Encoding
+		if enc == nil {
+			enc = UTF8
+			if input.Encoding != "" {
+				var ok bool
+				if enc, ok = Encodings[input.Encoding]; !ok {
+					return fmt.Errorf("%q has unknown encoding %q", n, input.Encoding)
+				}
+			}
+		}
+		if input.Rooms != nil {
+			buf := &bytes.Buffer{}
+			if err := d.WriteWldFile(buf, input.Rooms); err != nil {
+				return fmt.Errorf("failed to convert %q: %v", n, err)
+			}
+			b, err := enc.Encode(buf.String())
+			if err != nil {
+				return fmt.Errorf("failed to convert %q: %v", n, err)
+			}
+			if input.LineEnding == CRLF {
+				b = toCRLF(b)
+			}
+			if err := writeOutput(to, name, ".wld", b); err != nil {
+				return err
+			}
+		}
+		if input.Mobs != nil {
+			buf := &bytes.Buffer{}
+			if err := d.WriteMobFile(buf, input.Mobs); err != nil {
+				return fmt.Errorf("failed to convert %q: %v", n, err)
+			}
+			b, err := enc.Encode(buf.String())
+			if err != nil {
+				return fmt.Errorf("failed to convert %q: %v", n, err)
+			}
+			if input.LineEnding == CRLF {
+				b = toCRLF(b)
+			}
+			if err := writeOutput(to, name, ".mob", b); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
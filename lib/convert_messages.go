@@ -0,0 +1,172 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConvertMessages converts all the CircleMUD combat message files in the from
+// directory that match the pattern to json files in the to directory.
+func ConvertMessages(to, from, pattern string) error {
+	files, err := filepath.Glob(filepath.Join(from, pattern))
+	if err != nil {
+		return err
+	}
+	return ConvertMessageFiles(to, files, Options{})
+}
+
+// ConvertMessageFiles converts the given CircleMUD combat message files to
+// json files in the to directory.
+func ConvertMessageFiles(to string, files []string, opts Options) error {
+	if err := makeOutputDir(to); err != nil {
+		return err
+	}
+	for _, name := range files {
+		f, n, _, err := readInput(name, opts.Encoding)
+		if err != nil {
+			return err
+		}
+		msgs, err := ParseMessages(f, n)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(map[string]interface{}{"messages": msgs}, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to convert %q to json: %v", name, err)
+		}
+		if err := writeOutput(to, name, ".json", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AttackMessages are all the combat messages for one attack type.  When there
+// is more than one set of messages, the MUD picks one at random.
+type AttackMessages struct {
+	AttackType int          `json:"attack_type"`
+	Name       string       `json:"name,omitempty"`
+	Messages   []MessageSet `json:"messages"`
+}
+
+// MessageSet is a single M block from the messages file.
+type MessageSet struct {
+	Die  Message `json:"die"`
+	Miss Message `json:"miss"`
+	Hit  Message `json:"hit"`
+	God  Message `json:"god"`
+}
+
+// Message is what the attacker, the victim, and everyone else in the room see.
+// A message that is # in the file (meaning there is no message) is null.
+type Message struct {
+	ToAttacker *string `json:"to_attacker"`
+	ToVictim   *string `json:"to_victim"`
+	ToRoom     *string `json:"to_room"`
+}
+
+// TYPE_HIT is the attack type number of the first bare hand or weapon attack
+// type in the messages file.  Lower numbers are spells and skills.
+const TYPE_HIT = 300
+
+// ParseMessagesFile parses the given CircleMUD combat messages file.  Message
+// sets are grouped by attack type, in the order each attack type first
+// appears in the file.
+func ParseMessagesFile(filename string) ([]*AttackMessages, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseMessages(f, filename)
+}
+
+// ParseMessages parses combat messages from a messages file read from r.  The
+// name is used in error messages.
+func ParseMessages(r io.Reader, name string) (_ []*AttackMessages, err error) {
+	// need this because scan can panic if you send it too much stuff
+	defer func() {
+		panicErr := recover()
+		if panicErr == nil {
+			return
+		}
+		if e, ok := panicErr.(error); ok {
+			err = e
+			return
+		}
+		err = fmt.Errorf("%v", panicErr)
+	}()
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	defer func() {
+		if err != nil {
+			// add where in the file the error happened
+			err = scanner.parseError(name, err)
+		}
+	}()
+	msgs := []*AttackMessages{}
+	byType := map[int]*AttackMessages{}
+	for {
+		if !scanner.Scan() {
+			if err = scanner.Err(); err != nil {
+				return nil, err
+			}
+			return msgs, nil
+		}
+		s := strings.TrimSpace(scanner.Text())
+		switch {
+		case s == "", strings.HasPrefix(s, "*"):
+			// comment or blank line
+		case s == "$":
+			return msgs, nil
+		case s == "M":
+			typ, set, err := scanMessageSet(scanner)
+			if err != nil {
+				return nil, err
+			}
+			am, ok := byType[typ]
+			if !ok {
+				am = &AttackMessages{AttackType: typ}
+				if typ >= TYPE_HIT {
+					am.Name = AttackTypes[strconv.Itoa(typ-TYPE_HIT)]
+				}
+				byType[typ] = am
+				msgs = append(msgs, am)
+			}
+			am.Messages = append(am.Messages, *set)
+		default:
+			return nil, fmt.Errorf("unexpected token in messages file: %q", s)
+		}
+	}
+}
+
+func scanMessageSet(scanner *fileScanner) (int, *MessageSet, error) {
+	if err := scanner.MustScan(); err != nil {
+		return 0, nil, err
+	}
+	s := strings.TrimSpace(scanner.Text())
+	typ, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid attack type: %q", s)
+	}
+	set := &MessageSet{}
+	for _, m := range []*Message{&set.Die, &set.Miss, &set.Hit, &set.God} {
+		for _, msg := range []**string{&m.ToAttacker, &m.ToVictim, &m.ToRoom} {
+			if *msg, err = scanActionMessage(scanner); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+	return typ, set, nil
+}
@@ -2,9 +2,8 @@ package lib
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -13,93 +12,246 @@ import (
 
 // ConvertMobs converts all the CircleMUD mob files in the from directory
 // that match the pattern to json files in the to directory.
-func ConvertMobs(to, from, pattern string) (err error) {
-	if err := os.MkdirAll(to, 0700); err != nil {
-		return fmt.Errorf("couldn't create output directory: %v", err)
-	}
+func ConvertMobs(to, from, pattern string) error {
 	files, err := filepath.Glob(filepath.Join(from, pattern))
 	if err != nil {
 		return err
 	}
-	for _, name := range files {
-		mobs, err := ParseMobFile(name)
+	return ConvertMobFiles(to, files, Options{})
+}
+
+// ConvertMobFiles converts the given CircleMUD mob files to json files in the to
+// directory.  Mobs are converted one at a time, so files of any size can be
+// converted.
+func ConvertMobFiles(to string, files []string, opts Options) error {
+	return convertFiles(to, files, opts, convertMobFile)
+}
+
+// convertMobFile converts a single mob file.  It's a fileConverter.
+func convertMobFile(to, name string, opts Options) (n string, perrs []error, out *output, err error) {
+	f, n, enc, err := readInput(name, opts.Encoding)
+	if err != nil {
+		return name, []error{err}, nil, nil
+	}
+	defer f.Close()
+	o, err := createOutput(to, name, ".json")
+	if err != nil {
+		return n, nil, nil, err
+	}
+	defer func() {
 		if err != nil {
-			return err
+			o.discard()
+		}
+	}()
+	w, err := newRecordWriter(o, "mobs")
+	if err != nil {
+		return n, nil, nil, err
+	}
+	mr := opts.Dialect.NewMobReader(f, n)
+	for {
+		m, err := mr.Next()
+		if err == io.EOF {
+			break
 		}
-		b, err := json.MarshalIndent(map[string]interface{}{"mobs": mobs}, "", "    ")
 		if err != nil {
-			return fmt.Errorf("failed to convert %q to json: %v", name, err)
+			perrs = append(perrs, err)
+			if !opts.KeepGoing {
+				break
+			}
+			continue
 		}
-		n := filepath.Base(name)
-		ext := filepath.Ext(n)
-		n = n[:len(n)-len(ext)] + ".json"
-		n = filepath.Join(to, n)
-
-		if err := ioutil.WriteFile(n, b, 0600); err != nil {
-			return err
+		if !opts.Lossless {
+			m.clearRaw()
 		}
+		opts.renderColors(fmt.Sprintf("%s: mob %d", n, m.Number),
+			colorField{"short description", &m.ShortDesc},
+			colorField{"long description", &m.LongDesc},
+			colorField{"detailed description", &m.DetailedDesc})
+		if err := w.Write(m); err != nil {
+			return n, perrs, nil, fmt.Errorf("failed to convert %q to json: %v", name, err)
+		}
+	}
+	err = w.Close(
+		jsonField{"line_ending", lineEnding(mr.CRLF())},
+		jsonField{"encoding", encodingName(enc)},
+		jsonField{"dialect", dialectName(mr.Detected())},
+	)
+	if err == nil {
+		err = o.close()
+	}
+	if err != nil {
+		return n, perrs, nil, err
+	}
+	if len(perrs) > 0 && (!opts.WritePartial || w.count == 0) {
+		o.discard()
+		return n, perrs, nil, nil
 	}
-	return nil
+	return n, perrs, o, nil
 }
 
 // ParseMobFile parses the given CircleMUD mob file.
-func ParseMobFile(filename string) (_ []*Mob, err error) {
-	// need this because scan can panic if you send it too much stuff
-	defer func() {
-		panicErr := recover()
-		if panicErr == nil {
-			return
-		}
-		if e, ok := panicErr.(error); ok {
-			err = e
-			return
-		}
-		err = fmt.Errorf("%v", panicErr)
-	}()
+func ParseMobFile(filename string) ([]*Mob, error) {
+	return (*Dialect)(nil).ParseMobFile(filename)
+}
+
+// ParseMobs parses mobs from a mob file read from r.  The name is used in error
+// messages.
+func ParseMobs(r io.Reader, name string) ([]*Mob, error) {
+	return (*Dialect)(nil).ParseMobs(r, name)
+}
 
+// ParseMobFile parses the given mob file using the tables from the dialect.
+// If d is nil, the dialect is chosen based on the format of each mob.
+func (d *Dialect) ParseMobFile(filename string) ([]*Mob, error) {
 	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	return d.ParseMobs(f, filename)
+}
 
-	line := 0
-	scanner := &fileScanner{
-		line:    &line,
-		Scanner: bufio.NewScanner(f),
+// ParseMobs parses mobs from a mob file read from r using the tables from the
+// dialect.  The name is used in error messages.  If d is nil, the dialect is
+// chosen based on the format of each record.
+func (d *Dialect) ParseMobs(r io.Reader, name string) ([]*Mob, error) {
+	mobs, _, errs := d.parseMobs(r, name, false)
+	if len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return mobs, nil
+}
+
+// parseMobs parses mobs from r.  If keepGoing is set, it skips to the next
+// mob after an error and returns all the mobs that could be parsed, along
+// with every error.  Otherwise it stops at the first error.
+func (d *Dialect) parseMobs(r io.Reader, name string, keepGoing bool) (mobs []*Mob, crlf bool, errs []error) {
+	mr := d.NewMobReader(r, name)
+	mobs = []*Mob{}
+	for {
+		mob, err := mr.Next()
+		if err == io.EOF {
+			return mobs, mr.CRLF(), errs
+		}
+		if err != nil {
+			errs = append(errs, err)
+			if !keepGoing {
+				return mobs, mr.CRLF(), errs
+			}
+			continue
+		}
+		mobs = append(mobs, mob)
 	}
+}
+
+// MobReader reads the mobs from a mob file one at a time, so that files too
+// big to fit in memory can be converted.
+type MobReader struct {
+	scanner *fileScanner
+	name    string
+	line    int
+	started bool
+	// start is the line the last mob started on, and failed is set if it
+	// couldn't be parsed.
+	start  int
+	failed bool
+	done   bool
+}
+
+// NewMobReader returns a MobReader that reads mobs from a mob file read from
+// r.  The name is used in error messages.
+func NewMobReader(r io.Reader, name string) *MobReader {
+	return (*Dialect)(nil).NewMobReader(r, name)
+}
+
+// NewMobReader returns a MobReader that reads mobs from a mob file read from r
+// using the tables from the dialect.  The name is used in error messages.  If
+// d is nil, the dialect is chosen based on the format of each mob.
+func (d *Dialect) NewMobReader(r io.Reader, name string) *MobReader {
+	mr := &MobReader{name: name}
+	mr.scanner = &fileScanner{
+		line:    &mr.line,
+		Scanner: bufio.NewScanner(r),
+		dialect: d,
+	}
+	return mr
+}
+
+// Next returns the next mob in the file, or io.EOF after the last one.  If a
+// mob can't be parsed, Next returns a *ParseError, and calling Next again
+// skips to the mob after it.
+func (mr *MobReader) Next() (mob *Mob, err error) {
+	if mr.done {
+		return nil, io.EOF
+	}
+	// need this because scan can panic if you send it too much stuff
 	defer func() {
-		if err != nil {
-			// add filename and line number to error
-			err = fmt.Errorf("%s:%v - %s", filename, line, err)
+		panicErr := recover()
+		if panicErr == nil {
+			return
 		}
+		mr.done = true
+		e, ok := panicErr.(error)
+		if !ok {
+			e = fmt.Errorf("%v", panicErr)
+		}
+		err = mr.scanner.parseError(mr.name, e)
 	}()
-	mobs := []*Mob{}
-	if err := scanner.MustScan(); err != nil {
-		return nil, err
-	}
-	for {
-		if strings.TrimSpace(scanner.Text()) == "$" {
-			return mobs, nil
+
+	scanner := mr.scanner
+	switch {
+	case !mr.started:
+		mr.started = true
+		if err := scanner.MustScan(); err != nil {
+			mr.done = true
+			return nil, scanner.parseError(mr.name, err)
 		}
-		mob, err := scanMob(scanner)
-		if err != nil {
-			return nil, err
+	case mr.failed:
+		mr.failed = false
+		if !scanner.skipToRecord(mr.start) {
+			mr.done = true
+			return nil, io.EOF
 		}
-		mobs = append(mobs, mob)
 	}
+	if strings.TrimSpace(scanner.Text()) == "$" {
+		mr.done = true
+		return nil, io.EOF
+	}
+	mr.start = mr.line
+	mob, err = scanMob(scanner)
+	if err != nil {
+		// add where in the file the error happened
+		mr.failed = true
+		return nil, scanner.parseError(mr.name, err)
+	}
+	return mob, nil
+}
+
+// Detected returns the dialect that was chosen from the format of the records
+// read so far, if the reader was made without a dialect.  If any record had
+// tbaMUD's 128 bit flags, it's TbaMUD.  It returns nil if no dialect was
+// chosen.
+func (mr *MobReader) Detected() *Dialect {
+	return mr.scanner.detected
+}
+
+// CRLF reports whether most of the lines read so far ended in \r\n.
+func (mr *MobReader) CRLF() bool {
+	return mr.scanner.usesCRLF()
 }
 
 func scanMob(scanner *fileScanner) (*Mob, error) {
+	scanner.endRecord()
 	number := strings.TrimSpace(scanner.Text())
 	if !strings.HasPrefix(number, "#") {
-		return nil, fmt.Errorf("mob number must start with #, but found: %q", number)
+		return nil, expectedError("mob number to start with #", number)
 	}
 	num, err := strconv.Atoi(number[1:])
 	if err != nil {
 		return nil, fmt.Errorf("mob number %q not a number: %v", number[1:], err)
 	}
 	m := Mob{Number: num}
+	scanner.startRecord(num)
 
 	d, err := scanner.ScanUntil("~")
 	if err != nil {
@@ -126,20 +278,34 @@ func scanMob(scanner *fileScanner) (*Mob, error) {
 		return nil, err
 	}
 	fields := strings.Fields(scanner.Text())
-	if len(fields) != 4 {
-		return nil, fmt.Errorf("expected mob metadata to be <action_bits> <affection_bits> <alignment> <type>, but got %q", scanner.Text())
-	}
-	actions, err := MobActionsToNames(fields[0])
-	if err != nil {
-		return nil, err
+	var dialect *Dialect
+	var actions, affections []string
+	switch len(fields) {
+	case 4:
+		dialect = scanner.forFormat(false)
+		if actions, err = FlagsToNames(fields[0:1], dialect.MobFlags); err != nil {
+			return nil, err
+		}
+		if affections, err = FlagsToNames(fields[1:2], dialect.AffectFlags); err != nil {
+			return nil, err
+		}
+	case 10:
+		// tbaMUD's 128 bit flags: four words of action bits, then four of affection bits
+		dialect = scanner.forFormat(true)
+		if actions, err = FlagsToNames(fields[0:4], dialect.MobFlags); err != nil {
+			return nil, err
+		}
+		if affections, err = FlagsToNames(fields[4:8], dialect.AffectFlags); err != nil {
+			return nil, err
+		}
+		fields = []string{strings.Join(fields[0:4], " "), strings.Join(fields[4:8], " "), fields[8], fields[9]}
+	default:
+		return nil, expectedError("mob metadata to be <action_bits> <affection_bits> <alignment> <type>", scanner.Text())
 	}
 	m.Actions = actions
-
-	affections, err := MobAffectionsToNames(fields[1])
-	if err != nil {
-		return nil, err
-	}
+	m.ActionsRaw = fields[0]
 	m.Affections = affections
+	m.AffectionsRaw = fields[1]
 
 	alignment, err := strconv.Atoi(fields[2])
 	if err != nil {
@@ -148,11 +314,12 @@ func scanMob(scanner *fileScanner) (*Mob, error) {
 	m.Alignment = alignment
 
 	mobtype := fields[3]
+	m.TypeRaw = mobtype
 	switch mobtype {
 	case "S", "E", "W", "W1", "W2", "W3":
 		// ok
 	default:
-		return nil, fmt.Errorf("expected mob type to be S, W, or E, but was %v", mobtype)
+		return nil, expectedError("mob type to be S, W, or E", mobtype)
 	}
 	// I like how there's 3 different "types" but then they all have the same first 3 lines....
 	if err := scanner.MustScan(); err != nil {
@@ -160,7 +327,7 @@ func scanMob(scanner *fileScanner) (*Mob, error) {
 	}
 	fields = strings.Fields(scanner.Text())
 	if len(fields) != 5 {
-		return nil, fmt.Errorf("expected mob metadata to be <level> <thac0> <armor class> <max hit points> <bare hand damage>, but got %q", scanner.Text())
+		return nil, expectedError("mob metadata to be <level> <thac0> <armor class> <max hit points> <bare hand damage>", scanner.Text())
 	}
 	level, err := strconv.Atoi(fields[0])
 	if err != nil {
@@ -188,7 +355,7 @@ func scanMob(scanner *fileScanner) (*Mob, error) {
 	}
 	fields = strings.Fields(scanner.Text())
 	if len(fields) != 2 {
-		return nil, fmt.Errorf("expected mob metadata to be <gold> <experience points>, but got %q", scanner.Text())
+		return nil, expectedError("mob metadata to be <gold> <experience points>", scanner.Text())
 	}
 	gold, err := strconv.Atoi(fields[0])
 	if err != nil {
@@ -207,31 +374,113 @@ func scanMob(scanner *fileScanner) (*Mob, error) {
 	}
 	fields = strings.Fields(scanner.Text())
 	if len(fields) != 3 {
-		return nil, fmt.Errorf("expected mob metadata to be <load position> <default position> <sex>, but got %q", scanner.Text())
+		return nil, expectedError("mob metadata to be <load position> <default position> <sex>", scanner.Text())
 	}
-	pos, ok := PositionNames[fields[0]]
+	pos, ok := dialect.Positions[fields[0]]
 	if !ok {
 		return nil, fmt.Errorf("unknown position: %s", fields[0])
 	}
 	m.LoadPosition = pos
-	pos, ok = PositionNames[fields[1]]
+	m.LoadPositionRaw = fields[0]
+	pos, ok = dialect.Positions[fields[1]]
 	if !ok {
 		return nil, fmt.Errorf("unknown position: %s", fields[1])
 	}
 	m.DefaultPosition = pos
+	m.DefaultPositionRaw = fields[1]
 
-	gender, ok := genders[fields[2]]
+	gender, ok := dialect.Genders[fields[2]]
 	if !ok {
 		return nil, fmt.Errorf("unknown gender: %s", fields[2])
 	}
 	m.Gender = gender
+	m.GenderRaw = fields[2]
 
-	// for now just discard anything else
-	if _, err := scanner.ScanUntilPrefix([]string{"#", "$"}); err != nil {
-		return nil, err
+	if mobtype == "E" {
+		if err := scanMobESpecs(scanner, &m); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		if err := scanner.MustScan(); err != nil {
+			return nil, err
+		}
+		s := scanner.Text()
+		switch {
+		case strings.HasPrefix(s, "#"), strings.HasPrefix(s, "$"):
+			return &m, nil
+		case strings.HasPrefix(s, "T "):
+			trig, err := scanTriggerAttachment(s)
+			if err != nil {
+				return nil, err
+			}
+			m.Triggers = append(m.Triggers, trig)
+		case strings.TrimSpace(s) == "":
+		default:
+			m.Trailing = append(m.Trailing, s)
+		}
 	}
+}
 
-	return &m, nil
+// scanMobESpecs parses the <key>: <value> lines of an E type mob, up to the
+// terminating E line.
+func scanMobESpecs(scanner *fileScanner, m *Mob) error {
+	for {
+		if err := scanner.MustScan(); err != nil {
+			return err
+		}
+		s := strings.TrimSpace(scanner.Text())
+		if s == "E" {
+			return nil
+		}
+		parts := strings.SplitN(s, ":", 2)
+		if len(parts) != 2 {
+			return expectedError("E-spec to be <key>: <value>", s)
+		}
+		key, val := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		var stat **int
+		switch strings.ToLower(key) {
+		case "barehandattack":
+			attack, ok := AttackTypes[val]
+			if !ok {
+				// keep attack types we don't know, so they can be written back.
+				if m.Extra == nil {
+					m.Extra = map[string]string{}
+				}
+				m.Extra[key] = val
+				continue
+			}
+			m.BareHandAttack = attack
+			m.BareHandAttackRaw = val
+			continue
+		case "str":
+			stat = &m.Str
+		case "stradd":
+			stat = &m.StrAdd
+		case "int":
+			stat = &m.Int
+		case "wis":
+			stat = &m.Wis
+		case "dex":
+			stat = &m.Dex
+		case "con":
+			stat = &m.Con
+		case "cha":
+			stat = &m.Cha
+		default:
+			if m.Extra == nil {
+				m.Extra = map[string]string{}
+			}
+			m.Extra[key] = val
+			continue
+		}
+		num, err := strconv.Atoi(val)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %q", key, val)
+		}
+		*stat = &num
+	}
 }
 
 type Mob struct {
@@ -253,6 +502,45 @@ type Mob struct {
 	LoadPosition    string
 	DefaultPosition string
 	Gender          string
+	Triggers        []int
+	// Trailing is the lines after the mob that aren't triggers, such as
+	// another MUD's additions to the format.  They're written back as they
+	// are.
+	Trailing []string `json:",omitempty"`
+
+	// Extended (E type) mob attributes.  These are only set if the mob
+	// specifies them.
+	BareHandAttack string            `json:",omitempty"`
+	Str            *int              `json:",omitempty"`
+	StrAdd         *int              `json:",omitempty"`
+	Int            *int              `json:",omitempty"`
+	Wis            *int              `json:",omitempty"`
+	Dex            *int              `json:",omitempty"`
+	Con            *int              `json:",omitempty"`
+	Cha            *int              `json:",omitempty"`
+	Extra          map[string]string `json:",omitempty"`
+
+	// The raw values from the file.  The Convert functions only keep these in
+	// lossless mode.
+	TypeRaw            string `json:",omitempty"`
+	ActionsRaw         string `json:",omitempty"`
+	AffectionsRaw      string `json:",omitempty"`
+	LoadPositionRaw    string `json:",omitempty"`
+	DefaultPositionRaw string `json:",omitempty"`
+	GenderRaw          string `json:",omitempty"`
+	BareHandAttackRaw  string `json:",omitempty"`
+}
+
+// clearRaw removes the raw values from the file, for when they weren't asked
+// for.
+func (m *Mob) clearRaw() {
+	m.TypeRaw = ""
+	m.ActionsRaw = ""
+	m.AffectionsRaw = ""
+	m.LoadPositionRaw = ""
+	m.DefaultPositionRaw = ""
+	m.GenderRaw = ""
+	m.BareHandAttackRaw = ""
 }
 
 var PositionNames = map[string]string{
@@ -272,3 +560,22 @@ var genders = map[string]string{
 	"1": "Male",
 	"2": "Female",
 }
+
+// AttackTypes is the conversion between CircleMUD's attack type number and a human-readable string.
+var AttackTypes = map[string]string{
+	"0":  "hit",
+	"1":  "sting",
+	"2":  "whip",
+	"3":  "slash",
+	"4":  "bite",
+	"5":  "bludgeon",
+	"6":  "crush",
+	"7":  "pound",
+	"8":  "claw",
+	"9":  "maul",
+	"10": "thrash",
+	"11": "pierce",
+	"12": "blast",
+	"13": "punch",
+	"14": "stab",
+}
@@ -0,0 +1,300 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConvertObjects converts all the CircleMUD object files in the from directory
+// that match the pattern to json files in the to directory.
+func ConvertObjects(to, from, pattern string) error {
+	files, err := filepath.Glob(filepath.Join(from, pattern))
+	if err != nil {
+		return err
+	}
+	return ConvertObjectFiles(to, files, Options{})
+}
+
+// ConvertObjectFiles converts the given CircleMUD object files to json files in the to
+// directory.
+func ConvertObjectFiles(to string, files []string, opts Options) error {
+	if err := makeOutputDir(to); err != nil {
+		return err
+	}
+	for _, name := range files {
+		f, n, _, err := readInput(name, opts.Encoding)
+		if err != nil {
+			return err
+		}
+		objs, err := ParseObjects(f, n)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if !opts.Lossless {
+			for _, o := range objs {
+				o.TypeRaw, o.ExtrasRaw, o.WearRaw = "", "", ""
+			}
+		}
+		for _, o := range objs {
+			what := fmt.Sprintf("%s: object %d", n, o.Number)
+			opts.renderColors(what,
+				colorField{"short description", &o.ShortDesc},
+				colorField{"long description", &o.LongDesc},
+				colorField{"action description", &o.ActionDesc})
+			for i := range o.ExtraDescs {
+				opts.renderColors(what, colorField{"extra description", &o.ExtraDescs[i].Description})
+			}
+		}
+		b, err := json.MarshalIndent(map[string]interface{}{"objects": objs}, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to convert %q to json: %v", name, err)
+		}
+		if err := writeOutput(to, name, ".json", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Object is a representation of an item in a MUD.
+type Object struct {
+	Number     int         `json:"number"`
+	Aliases    []string    `json:"aliases"`
+	ShortDesc  string      `json:"short_desc"`
+	LongDesc   string      `json:"long_desc"`
+	ActionDesc string      `json:"action_desc"`
+	Type       string      `json:"type"`
+	Extras     []string    `json:"extra_bits"`
+	Wear       []string    `json:"wear_bits"`
+	Values     [4]int      `json:"values"`
+	Weight     int         `json:"weight"`
+	Cost       int         `json:"cost"`
+	Rent       int         `json:"rent"`
+	ExtraDescs []ExtraDesc `json:"extra_descs"`
+	Affects    []Affect    `json:"affects"`
+	Triggers   []int       `json:"triggers"`
+	TypeRaw    string      `json:"type_raw,omitempty"`
+	ExtrasRaw  string      `json:"extra_bits_raw,omitempty"`
+	WearRaw    string      `json:"wear_bits_raw,omitempty"`
+}
+
+// Affect is a modifier an object applies to the character using it.
+type Affect struct {
+	Location string `json:"location"`
+	Value    int    `json:"value"`
+}
+
+// ParseObjFile parses the given CircleMUD object file.
+func ParseObjFile(filename string) ([]*Object, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseObjects(f, filename)
+}
+
+// ParseObjects parses objects from an obj file read from r.  The name is used
+// in error messages.
+func ParseObjects(r io.Reader, name string) (_ []*Object, err error) {
+	// need this because scan can panic if you send it too much stuff
+	defer func() {
+		panicErr := recover()
+		if panicErr == nil {
+			return
+		}
+		if e, ok := panicErr.(error); ok {
+			err = e
+			return
+		}
+		err = fmt.Errorf("%v", panicErr)
+	}()
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	defer func() {
+		if err != nil {
+			// add where in the file the error happened
+			err = scanner.parseError(name, err)
+		}
+	}()
+	objs := []*Object{}
+	if err := scanner.MustScan(); err != nil {
+		return nil, err
+	}
+	for {
+		if strings.TrimSpace(scanner.Text()) == "$" {
+			return objs, nil
+		}
+		obj, err := scanObj(scanner)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+}
+
+func scanObj(scanner *fileScanner) (*Object, error) {
+	scanner.endRecord()
+	number := strings.TrimSpace(scanner.Text())
+	if !strings.HasPrefix(number, "#") {
+		return nil, expectedError("object number to start with #", number)
+	}
+	num, err := strconv.Atoi(number[1:])
+	if err != nil {
+		return nil, fmt.Errorf("object number %q not a number: %v", number[1:], err)
+	}
+	o := Object{Number: num}
+	scanner.startRecord(num)
+
+	d, err := scanner.ScanUntil("~")
+	if err != nil {
+		return nil, err
+	}
+	o.Aliases = strings.Fields(d)
+
+	d, err = scanner.ScanUntil("~")
+	if err != nil {
+		return nil, err
+	}
+	o.ShortDesc = d
+	d, err = scanner.ScanUntil("~")
+	if err != nil {
+		return nil, err
+	}
+	o.LongDesc = d
+	d, err = scanner.ScanUntil("~")
+	if err != nil {
+		return nil, err
+	}
+	o.ActionDesc = d
+
+	if err := scanner.MustScan(); err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) != 3 {
+		return nil, expectedError("object metadata to be <type flag> <extra bitvector> <wear bitvector>", scanner.Text())
+	}
+	typ, ok := ObjTypes[fields[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown object type: %q", fields[0])
+	}
+	o.Type = typ
+	o.TypeRaw = fields[0]
+
+	extras, err := ObjExtrasToNames(fields[1])
+	if err != nil {
+		return nil, err
+	}
+	o.Extras = extras
+	o.ExtrasRaw = fields[1]
+
+	wear, err := ObjWearToNames(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	o.Wear = wear
+	o.WearRaw = fields[2]
+
+	if err := scanner.MustScan(); err != nil {
+		return nil, err
+	}
+	fields = strings.Fields(scanner.Text())
+	if len(fields) != 4 {
+		return nil, expectedError("object values to be <value 0> <value 1> <value 2> <value 3>", scanner.Text())
+	}
+	for i, f := range fields {
+		v, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value %d: %q", i, f)
+		}
+		o.Values[i] = v
+	}
+
+	if err := scanner.MustScan(); err != nil {
+		return nil, err
+	}
+	fields = strings.Fields(scanner.Text())
+	if len(fields) != 3 {
+		return nil, expectedError("object metadata to be <weight> <cost> <rent per day>", scanner.Text())
+	}
+	weight, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("invalid weight: %q", fields[0])
+	}
+	o.Weight = weight
+
+	cost, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid cost: %q", fields[1])
+	}
+	o.Cost = cost
+
+	rent, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid rent: %q", fields[2])
+	}
+	o.Rent = rent
+
+	for {
+		// optional stuff, until the next object or the end of the file
+		if err := scanner.MustScan(); err != nil {
+			return nil, err
+		}
+		s := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(s, "#"), s == "$":
+			return &o, nil
+		case s == "E":
+			ex, err := scanExtra(scanner)
+			if err != nil {
+				return nil, err
+			}
+			o.ExtraDescs = append(o.ExtraDescs, *ex)
+		case s == "A":
+			aff, err := scanAffect(scanner)
+			if err != nil {
+				return nil, err
+			}
+			o.Affects = append(o.Affects, *aff)
+		case strings.HasPrefix(s, "T "):
+			trig, err := scanTriggerAttachment(s)
+			if err != nil {
+				return nil, err
+			}
+			o.Triggers = append(o.Triggers, trig)
+		default:
+			return nil, fmt.Errorf("unexpected token in object definition: %q", s)
+		}
+	}
+}
+
+func scanAffect(scanner *fileScanner) (*Affect, error) {
+	if err := scanner.MustScan(); err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) != 2 {
+		return nil, expectedError("affect fields to be <location> <value>", scanner.Text())
+	}
+	loc, ok := ApplyLocations[fields[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown affect location: %q", fields[0])
+	}
+	val, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid affect value: %q", fields[1])
+	}
+	return &Affect{Location: loc, Value: val}, nil
+}
@@ -0,0 +1,426 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConvertPlayers converts all the tbaMUD ASCII player files in the from
+// directory that match the pattern to json files in the to directory.
+func ConvertPlayers(to, from, pattern string) error {
+	files, err := filepath.Glob(filepath.Join(from, pattern))
+	if err != nil {
+		return err
+	}
+	return ConvertPlayerFiles(to, files, Options{})
+}
+
+// ConvertPlayerFiles converts the given tbaMUD ASCII player files to json
+// files in the to directory.
+func ConvertPlayerFiles(to string, files []string, opts Options) error {
+	if err := makeOutputDir(to); err != nil {
+		return err
+	}
+	for _, name := range files {
+		f, n, _, err := readInput(name, opts.Encoding)
+		if err != nil {
+			return err
+		}
+		p, err := ParsePlayer(f, n)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if !opts.Passwords {
+			p.Password = ""
+		}
+		b, err := json.MarshalIndent(p, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to convert %q to json: %v", name, err)
+		}
+		if err := writeOutput(to, name, ".json", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Player is a player character, as saved in a tbaMUD ASCII player file.
+type Player struct {
+	Name string `json:"name"`
+	ID   int    `json:"id"`
+	// Password is the player's password hash.  ConvertPlayerFiles leaves it
+	// out unless Options.Passwords is set.
+	Password    string         `json:"password,omitempty"`
+	Description string         `json:"description"`
+	Title       string         `json:"title"`
+	Gender      string         `json:"gender"`
+	Class       string         `json:"class"`
+	Level       int            `json:"level"`
+	Hometown    int            `json:"hometown"`
+	Birth       int64          `json:"birth"`
+	Played      int64          `json:"played"`
+	Last        int64          `json:"last_logon"`
+	Host        string         `json:"host"`
+	Height      int            `json:"height"`
+	Weight      int            `json:"weight"`
+	Alignment   int            `json:"alignment"`
+	Actions     []string       `json:"actions"`
+	Affections  []string       `json:"affections"`
+	Preferences []string       `json:"preferences"`
+	Room        int            `json:"room"`
+	Str         int            `json:"str"`
+	StrAdd      int            `json:"str_add"`
+	Int         int            `json:"int"`
+	Wis         int            `json:"wis"`
+	Dex         int            `json:"dex"`
+	Con         int            `json:"con"`
+	Cha         int            `json:"cha"`
+	Hit         int            `json:"hit"`
+	MaxHit      int            `json:"max_hit"`
+	Mana        int            `json:"mana"`
+	MaxMana     int            `json:"max_mana"`
+	Move        int            `json:"move"`
+	MaxMove     int            `json:"max_move"`
+	AC          int            `json:"ac"`
+	Gold        int            `json:"gold"`
+	Bank        int            `json:"bank"`
+	XP          int            `json:"xp"`
+	Hitroll     int            `json:"hitroll"`
+	Damroll     int            `json:"damroll"`
+	Skills      []Skill        `json:"skills"`
+	Affects     []PlayerAffect `json:"affects"`
+	Aliases     []Alias        `json:"aliases"`
+
+	// Extra holds the tags this parser doesn't know about, by tag name.
+	Extra map[string]string `json:"extra,omitempty"`
+}
+
+// Skill is how well a player has learned a skill or spell.
+type Skill struct {
+	Number  int `json:"number"`
+	Learned int `json:"learned"`
+}
+
+// PlayerAffect is a spell or skill currently affecting a player.
+type PlayerAffect struct {
+	Spell      int      `json:"spell"`
+	Duration   int      `json:"duration"`
+	Modifier   int      `json:"modifier"`
+	Location   string   `json:"location"`
+	Affections []string `json:"affections"`
+}
+
+// Alias is a command alias a player has set up.
+type Alias struct {
+	Alias       string `json:"alias"`
+	Replacement string `json:"replacement"`
+	Type        int    `json:"type"`
+}
+
+// ParsePlayerFile parses the given tbaMUD ASCII player file.
+func ParsePlayerFile(filename string) (*Player, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParsePlayer(f, filename)
+}
+
+// ParsePlayer parses an ASCII player file read from r.  The name is used in
+// error messages.
+func ParsePlayer(r io.Reader, name string) (_ *Player, err error) {
+	// need this because scan can panic if you send it too much stuff
+	defer func() {
+		panicErr := recover()
+		if panicErr == nil {
+			return
+		}
+		if e, ok := panicErr.(error); ok {
+			err = e
+			return
+		}
+		err = fmt.Errorf("%v", panicErr)
+	}()
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	defer func() {
+		if err != nil {
+			// add where in the file the error happened
+			err = scanner.parseError(name, err)
+		}
+	}()
+	return scanPlayer(scanner)
+}
+
+func scanPlayer(scanner *fileScanner) (*Player, error) {
+	p := Player{}
+	// the last unknown tag, so that any lines following it that aren't tags
+	// can be kept with it.
+	unknown := ""
+	for scanner.Scan() {
+		s := scanner.Text()
+		tag, val, ok := splitPlayerTag(s)
+		if !ok {
+			if unknown != "" {
+				p.Extra[unknown] += "\n" + s
+				continue
+			}
+			if strings.TrimSpace(s) == "" {
+				continue
+			}
+			return nil, expectedError("player data to be <tag>: <value>", s)
+		}
+		unknown = ""
+		var err error
+		switch tag {
+		case "Name":
+			p.Name = val
+		case "Pass":
+			p.Password = val
+		case "Id":
+			p.ID, err = strconv.Atoi(val)
+		case "Desc":
+			p.Description, err = scanner.ScanUntil("~")
+		case "Titl":
+			p.Title = val
+		case "Sex":
+			g, ok := genders[val]
+			if !ok {
+				return nil, fmt.Errorf("unknown gender: %s", val)
+			}
+			p.Gender = g
+		case "Clas":
+			c, ok := ClassNames[val]
+			if !ok {
+				return nil, fmt.Errorf("unknown class: %s", val)
+			}
+			p.Class = c
+		case "Levl":
+			p.Level, err = strconv.Atoi(val)
+		case "Home":
+			p.Hometown, err = strconv.Atoi(val)
+		case "Brth":
+			p.Birth, err = strconv.ParseInt(val, 10, 64)
+		case "Plyd":
+			p.Played, err = strconv.ParseInt(val, 10, 64)
+		case "Last":
+			p.Last, err = strconv.ParseInt(val, 10, 64)
+		case "Host":
+			p.Host = val
+		case "Hite":
+			p.Height, err = strconv.Atoi(val)
+		case "Wate":
+			p.Weight, err = strconv.Atoi(val)
+		case "Alin":
+			p.Alignment, err = strconv.Atoi(val)
+		case "Act":
+			p.Actions, err = FlagsToNames(strings.Fields(val), TbaPlayerFlags)
+		case "Aff":
+			p.Affections, err = FlagsToNames(strings.Fields(val), TbaAffectFlags)
+		case "Pref":
+			p.Preferences, err = FlagsToNames(strings.Fields(val), TbaPrefFlags)
+		case "Room":
+			p.Room, err = strconv.Atoi(val)
+		case "Str":
+			parts := strings.SplitN(val, "/", 2)
+			p.Str, err = strconv.Atoi(parts[0])
+			if err == nil && len(parts) == 2 {
+				p.StrAdd, err = strconv.Atoi(parts[1])
+			}
+		case "Int":
+			p.Int, err = strconv.Atoi(val)
+		case "Wis":
+			p.Wis, err = strconv.Atoi(val)
+		case "Dex":
+			p.Dex, err = strconv.Atoi(val)
+		case "Con":
+			p.Con, err = strconv.Atoi(val)
+		case "Cha":
+			p.Cha, err = strconv.Atoi(val)
+		case "Hit":
+			p.Hit, p.MaxHit, err = playerPoints(val)
+		case "Mana":
+			p.Mana, p.MaxMana, err = playerPoints(val)
+		case "Move":
+			p.Move, p.MaxMove, err = playerPoints(val)
+		case "Ac":
+			p.AC, err = strconv.Atoi(val)
+		case "Gold":
+			p.Gold, err = strconv.Atoi(val)
+		case "Bank":
+			p.Bank, err = strconv.Atoi(val)
+		case "Exp":
+			p.XP, err = strconv.Atoi(val)
+		case "Hrol":
+			p.Hitroll, err = strconv.Atoi(val)
+		case "Drol":
+			p.Damroll, err = strconv.Atoi(val)
+		case "Skil":
+			p.Skills, err = scanPlayerSkills(scanner)
+		case "Affs":
+			p.Affects, err = scanPlayerAffects(scanner)
+		case "Alis":
+			p.Aliases, err = scanPlayerAliases(scanner, val)
+		default:
+			if p.Extra == nil {
+				p.Extra = map[string]string{}
+			}
+			p.Extra[tag] = val
+			unknown = tag
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", tag, err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// splitPlayerTag splits a player file line into its tag and value.  Tags are
+// up to four characters, padded with spaces, followed by a colon.
+func splitPlayerTag(s string) (tag, val string, ok bool) {
+	idx := strings.IndexByte(s, ':')
+	if idx < 1 || idx > 4 {
+		return "", "", false
+	}
+	for _, r := range s[:idx] {
+		if !(r == ' ' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return "", "", false
+		}
+	}
+	return strings.TrimSpace(s[:idx]), strings.TrimSpace(s[idx+1:]), true
+}
+
+// playerPoints parses a <current>/<max> value.
+func playerPoints(val string) (cur, max int, err error) {
+	parts := strings.Split(val, "/")
+	if len(parts) != 2 {
+		return 0, 0, expectedError("<current>/<max>", val)
+	}
+	if cur, err = strconv.Atoi(parts[0]); err != nil {
+		return 0, 0, err
+	}
+	if max, err = strconv.Atoi(parts[1]); err != nil {
+		return 0, 0, err
+	}
+	return cur, max, nil
+}
+
+// scanPlayerSkills reads <skill number> <learned> lines up to a line whose
+// skill number is 0.  tbaMUD ends the list with "0 0", older files with "0".
+func scanPlayerSkills(scanner *fileScanner) ([]Skill, error) {
+	skills := []Skill{}
+	for {
+		if err := scanner.MustScan(); err != nil {
+			return nil, err
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 && fields[0] == "0" {
+			return skills, nil
+		}
+		if len(fields) != 2 {
+			return nil, expectedError("skill to be <skill number> <learned>", scanner.Text())
+		}
+		num, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		learned, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, err
+		}
+		skills = append(skills, Skill{Number: num, Learned: learned})
+	}
+}
+
+// scanPlayerAffects reads <spell> <duration> <modifier> <location> <bitvector...>
+// lines up to a line whose spell is 0.
+func scanPlayerAffects(scanner *fileScanner) ([]PlayerAffect, error) {
+	affects := []PlayerAffect{}
+	for {
+		if err := scanner.MustScan(); err != nil {
+			return nil, err
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 0 && fields[0] == "0" {
+			return affects, nil
+		}
+		if len(fields) < 5 {
+			return nil, expectedError("affect to be <spell> <duration> <modifier> <location> <bitvector>", scanner.Text())
+		}
+		var a PlayerAffect
+		var err error
+		if a.Spell, err = strconv.Atoi(fields[0]); err != nil {
+			return nil, err
+		}
+		if a.Duration, err = strconv.Atoi(fields[1]); err != nil {
+			return nil, err
+		}
+		if a.Modifier, err = strconv.Atoi(fields[2]); err != nil {
+			return nil, err
+		}
+		loc, ok := ApplyLocations[fields[3]]
+		if !ok {
+			return nil, fmt.Errorf("unknown affect location: %q", fields[3])
+		}
+		a.Location = loc
+		if a.Affections, err = FlagsToNames(fields[4:], TbaAffectFlags); err != nil {
+			return nil, err
+		}
+		affects = append(affects, a)
+	}
+}
+
+// scanPlayerAliases reads count aliases, each of which is three lines: the
+// alias, its replacement, and its type.  If the count isn't on the tag line,
+// it's on the line after it.
+func scanPlayerAliases(scanner *fileScanner, count string) ([]Alias, error) {
+	if count == "" {
+		if err := scanner.MustScan(); err != nil {
+			return nil, err
+		}
+		count = strings.TrimSpace(scanner.Text())
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil {
+		return nil, err
+	}
+	aliases := []Alias{}
+	for i := 0; i < n; i++ {
+		var lines [3]string
+		for j := range lines {
+			if err := scanner.MustScan(); err != nil {
+				return nil, err
+			}
+			lines[j] = strings.TrimSpace(scanner.Text())
+		}
+		typ, err := strconv.Atoi(lines[2])
+		if err != nil {
+			return nil, err
+		}
+		aliases = append(aliases, Alias{Alias: lines[0], Replacement: lines[1], Type: typ})
+	}
+	return aliases, nil
+}
+
+// ClassNames is the conversion between CircleMUD's class number and a human-readable string.
+var ClassNames = map[string]string{
+	"0": "Magic User",
+	"1": "Cleric",
+	"2": "Thief",
+	"3": "Warrior",
+}
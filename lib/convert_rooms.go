@@ -2,9 +2,8 @@ package lib
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -13,38 +12,78 @@ import (
 
 // ConvertRooms converts all the CircleMUD world (room) files in the from directory
 // that match the pattern to json files in the to directory.
-func ConvertRooms(to, from, pattern string) (err error) {
-	if err := os.MkdirAll(to, 0700); err != nil {
-		return fmt.Errorf("couldn't create output directory: %v", err)
-	}
+func ConvertRooms(to, from, pattern string) error {
 	files, err := filepath.Glob(filepath.Join(from, pattern))
 	if err != nil {
 		return err
 	}
-	for _, name := range files {
-		r, err := ParseWldFile(name)
+	return ConvertRoomFiles(to, files, Options{})
+}
+
+// ConvertRoomFiles converts the given CircleMUD world (room) files to json files in the to
+// directory.  Rooms are converted one at a time, so files of any size can be
+// converted.
+func ConvertRoomFiles(to string, files []string, opts Options) error {
+	return convertFiles(to, files, opts, convertRoomFile)
+}
+
+// convertRoomFile converts a single wld file.  It's a fileConverter.
+func convertRoomFile(to, name string, opts Options) (n string, perrs []error, out *output, err error) {
+	f, n, enc, err := readInput(name, opts.Encoding)
+	if err != nil {
+		return name, []error{err}, nil, nil
+	}
+	defer f.Close()
+	o, err := createOutput(to, name, ".json")
+	if err != nil {
+		return n, nil, nil, err
+	}
+	defer func() {
 		if err != nil {
-			return err
+			o.discard()
 		}
-		output := struct {
-			Rooms []Room `json:"rooms"`
-		}{
-			Rooms: r,
+	}()
+	w, err := newRecordWriter(o, "rooms")
+	if err != nil {
+		return n, nil, nil, err
+	}
+	rr := opts.Dialect.NewRoomReader(f, n)
+	for {
+		r, err := rr.Next()
+		if err == io.EOF {
+			break
 		}
-		b, err := json.MarshalIndent(output, "", "    ")
 		if err != nil {
-			return fmt.Errorf("failed to convert %q to json: %v", name, err)
+			perrs = append(perrs, err)
+			if !opts.KeepGoing {
+				break
+			}
+			continue
 		}
-		n := filepath.Base(name)
-		ext := filepath.Ext(n)
-		n = n[:len(n)-len(ext)] + ".json"
-		n = filepath.Join(to, n)
-
-		if err := ioutil.WriteFile(n, b, 0600); err != nil {
-			return err
+		if !opts.Lossless {
+			r.clearRaw()
+		}
+		r.renderColors(n, opts)
+		if err := w.Write(r); err != nil {
+			return n, perrs, nil, fmt.Errorf("failed to convert %q to json: %v", name, err)
 		}
 	}
-	return nil
+	err = w.Close(
+		jsonField{"line_ending", lineEnding(rr.CRLF())},
+		jsonField{"encoding", encodingName(enc)},
+		jsonField{"dialect", dialectName(rr.Detected())},
+	)
+	if err == nil {
+		err = o.close()
+	}
+	if err != nil {
+		return n, perrs, nil, err
+	}
+	if len(perrs) > 0 && (!opts.WritePartial || w.count == 0) {
+		o.discard()
+		return n, perrs, nil, nil
+	}
+	return n, perrs, o, nil
 }
 
 // Room is a representation of a room in a MUD.
@@ -55,8 +94,11 @@ type Room struct {
 	Description string      `json:"description"`
 	Bits        []string    `json:"bits"`
 	Sector      string      `json:"sector"`
+	BitsRaw     string      `json:"bits_raw,omitempty"`
+	SectorRaw   string      `json:"sector_raw,omitempty"`
 	Exits       []Exit      `json:"exits"`
 	Extras      []ExtraDesc `json:"extra_descs"`
+	Triggers    []int       `json:"triggers"`
 }
 
 // Exit represents a way you may move out of a room.
@@ -67,6 +109,34 @@ type Exit struct {
 	DoorFlag    string   `json:"door_flag"`
 	KeyNumber   int      `json:"key_number"`
 	Destination int      `json:"destination"`
+	// Opposite is the direction that leads back from the destination.
+	Opposite string `json:"opposite,omitempty"`
+
+	DirectionRaw string `json:"direction_raw,omitempty"`
+	DoorFlagRaw  string `json:"door_flag_raw,omitempty"`
+}
+
+// clearRaw removes the raw values from the file, for when they weren't asked
+// for.
+func (r *Room) clearRaw() {
+	r.BitsRaw = ""
+	r.SectorRaw = ""
+	for i := range r.Exits {
+		r.Exits[i].DirectionRaw = ""
+		r.Exits[i].DoorFlagRaw = ""
+	}
+}
+
+// renderColors renders the color codes in the room's text with opts.Color.
+func (r *Room) renderColors(name string, opts Options) {
+	what := fmt.Sprintf("%s: room %d", name, r.Number)
+	opts.renderColors(what, colorField{"name", &r.Name}, colorField{"description", &r.Description})
+	for i := range r.Exits {
+		opts.renderColors(what, colorField{r.Exits[i].Direction + " exit description", &r.Exits[i].Description})
+	}
+	for i := range r.Extras {
+		opts.renderColors(what, colorField{"extra description", &r.Extras[i].Description})
+	}
 }
 
 // ExtraDesc represents other things you can look at in the room.
@@ -76,73 +146,174 @@ type ExtraDesc struct {
 }
 
 // ParseWldFile parses the given CircleMUD wld file.
-func ParseWldFile(filename string) (rooms []Room, err error) {
-	// need this because scan can panic if you send it too much stuff
-	defer func() {
-		panicErr := recover()
-		if panicErr == nil {
-			return
-		}
-		if e, ok := panicErr.(error); ok {
-			err = e
-			return
-		}
-		err = fmt.Errorf("%v", panicErr)
-	}()
+func ParseWldFile(filename string) ([]Room, error) {
+	return (*Dialect)(nil).ParseWldFile(filename)
+}
+
+// ParseRooms parses rooms from a wld file read from r.  The name is used in
+// error messages.
+func ParseRooms(r io.Reader, name string) ([]Room, error) {
+	return (*Dialect)(nil).ParseRooms(r, name)
+}
 
+// ParseWldFile parses the given wld file using the tables from the dialect.
+// If d is nil, the dialect is chosen based on the format of each room.
+func (d *Dialect) ParseWldFile(filename string) ([]Room, error) {
 	f, err := os.Open(filename)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
+	return d.ParseRooms(f, filename)
+}
 
-	line := 0
-	scanner := &fileScanner{
-		line:    &line,
-		Scanner: bufio.NewScanner(f),
+// ParseRooms parses rooms from a wld file read from r using the tables from the
+// dialect.  The name is used in error messages.  If d is nil, the dialect is
+// chosen based on the format of each record.
+func (d *Dialect) ParseRooms(r io.Reader, name string) ([]Room, error) {
+	rooms, _, errs := d.parseRooms(r, name, false)
+	if len(errs) > 0 {
+		return nil, errs[0]
 	}
-	defer func() {
-		if err != nil {
-			// add filename and line number to error
-			err = fmt.Errorf("%s:%v - %s", filename, line, err)
-		}
-	}()
+	return rooms, nil
+}
+
+// parseRooms parses rooms from r.  If keepGoing is set, it skips to the next
+// room after an error and returns all the rooms that could be parsed, along
+// with every error.  Otherwise it stops at the first error.
+func (d *Dialect) parseRooms(r io.Reader, name string, keepGoing bool) (rooms []Room, crlf bool, errs []error) {
+	rr := d.NewRoomReader(r, name)
 	for {
-		if !scanner.Scan() {
-			if err = scanner.Err(); err != nil {
-				return nil, err
-			}
-			// end of file, that's ok. Technically you're supposed to end the
-			// file with $, but it doesn't really seem to be necessary.
-			return rooms, nil
+		room, err := rr.Next()
+		if err == io.EOF {
+			return rooms, rr.CRLF(), errs
 		}
-		if strings.TrimSpace(scanner.Text()) == "$" {
-			return rooms, nil
-		}
-		room, err := scanRoom(scanner)
 		if err != nil {
-			return nil, err
+			errs = append(errs, err)
+			if !keepGoing {
+				return rooms, rr.CRLF(), errs
+			}
+			continue
 		}
 		rooms = append(rooms, *room)
 	}
 }
 
+// RoomReader reads the rooms from a wld file one at a time, so that files too
+// big to fit in memory can be converted.
+type RoomReader struct {
+	scanner *fileScanner
+	name    string
+	line    int
+	// start is the line the last room started on, and failed is set if it
+	// couldn't be parsed.
+	start  int
+	failed bool
+	done   bool
+}
+
+// NewRoomReader returns a RoomReader that reads rooms from a wld file read
+// from r.  The name is used in error messages.
+func NewRoomReader(r io.Reader, name string) *RoomReader {
+	return (*Dialect)(nil).NewRoomReader(r, name)
+}
+
+// NewRoomReader returns a RoomReader that reads rooms from a wld file read
+// from r using the tables from the dialect.  The name is used in error
+// messages.  If d is nil, the dialect is chosen based on the format of each
+// room.
+func (d *Dialect) NewRoomReader(r io.Reader, name string) *RoomReader {
+	rr := &RoomReader{name: name}
+	rr.scanner = &fileScanner{
+		line:    &rr.line,
+		Scanner: bufio.NewScanner(r),
+		dialect: d,
+	}
+	return rr
+}
+
+// Next returns the next room in the file, or io.EOF after the last one.  If a
+// room can't be parsed, Next returns a *ParseError, and calling Next again
+// skips to the room after it.
+func (rr *RoomReader) Next() (room *Room, err error) {
+	if rr.done {
+		return nil, io.EOF
+	}
+	// need this because scan can panic if you send it too much stuff
+	defer func() {
+		panicErr := recover()
+		if panicErr == nil {
+			return
+		}
+		rr.done = true
+		e, ok := panicErr.(error)
+		if !ok {
+			e = fmt.Errorf("%v", panicErr)
+		}
+		err = rr.scanner.parseError(rr.name, e)
+	}()
+
+	scanner := rr.scanner
+	if rr.failed {
+		rr.failed = false
+		if !scanner.skipToRecord(rr.start) {
+			rr.done = true
+			return nil, io.EOF
+		}
+	} else if !scanner.Scan() {
+		rr.done = true
+		if err := scanner.Err(); err != nil {
+			return nil, scanner.parseError(rr.name, err)
+		}
+		// end of file, that's ok. Technically you're supposed to end the
+		// file with $, but it doesn't really seem to be necessary.
+		return nil, io.EOF
+	}
+	if strings.TrimSpace(scanner.Text()) == "$" {
+		rr.done = true
+		return nil, io.EOF
+	}
+	rr.start = rr.line
+	room, err = scanRoom(scanner)
+	if err != nil {
+		// add where in the file the error happened
+		rr.failed = true
+		return nil, scanner.parseError(rr.name, err)
+	}
+	return room, nil
+}
+
+// Detected returns the dialect that was chosen from the format of the records
+// read so far, if the reader was made without a dialect.  If any record had
+// tbaMUD's 128 bit flags, it's TbaMUD.  It returns nil if no dialect was
+// chosen.
+func (rr *RoomReader) Detected() *Dialect {
+	return rr.scanner.detected
+}
+
+// CRLF reports whether most of the lines read so far ended in \r\n.
+func (rr *RoomReader) CRLF() bool {
+	return rr.scanner.usesCRLF()
+}
+
 func scanRoom(scanner *fileScanner) (*Room, error) {
+	scanner.endRecord()
 	number := strings.TrimSpace(scanner.Text())
 	if !strings.HasPrefix(number, "#") {
-		return nil, fmt.Errorf("room number must start with #, but found: %q", number)
+		return nil, expectedError("room number to start with #", number)
 	}
 	num, err := strconv.Atoi(number[1:])
 	if err != nil {
 		return nil, fmt.Errorf("room number %q not a number: %v", number[1:], err)
 	}
 	r := Room{Number: num}
+	scanner.startRecord(num)
 	if err := scanner.MustScan(); err != nil {
 		return nil, err
 	}
 	name := scanner.Text()
 	if !strings.HasSuffix(name, "~") {
-		return nil, fmt.Errorf("room name must end with ~, but found: %q", name)
+		return nil, expectedError("room name to end with ~", name)
 	}
 	r.Name = name[:len(name)-1]
 	desc, err := scanner.ScanUntil("~")
@@ -154,9 +325,25 @@ func scanRoom(scanner *fileScanner) (*Room, error) {
 		return nil, err
 	}
 	fields := strings.Fields(scanner.Text())
-	if len(fields) != 3 {
-		return nil, fmt.Errorf("expected room metadata to be <zone#> <bitvector> <sector>, but got %q", scanner.Text())
+	var d *Dialect
+	var bits []string
+	switch len(fields) {
+	case 3:
+		d = scanner.forFormat(false)
+		bits, err = FlagsToNames(fields[1:2], d.RoomFlags)
+	case 6:
+		// tbaMUD's 128 bit flags: <zone#> <flags1> <flags2> <flags3> <flags4> <sector>
+		d = scanner.forFormat(true)
+		bits, err = FlagsToNames(fields[1:5], d.RoomFlags)
+		fields = []string{fields[0], strings.Join(fields[1:5], " "), fields[5]}
+	default:
+		return nil, expectedError("room metadata to be <zone#> <bitvector> <sector>", scanner.Text())
 	}
+	if err != nil {
+		return nil, err
+	}
+	r.Bits = bits
+	r.BitsRaw = fields[1]
 
 	zone, err := strconv.Atoi(fields[0])
 	if err != nil {
@@ -164,16 +351,12 @@ func scanRoom(scanner *fileScanner) (*Room, error) {
 	}
 	r.Zone = zone
 
-	bits, err := BitVectorToNames(fields[1])
-	if err != nil {
-		return nil, err
-	}
-	r.Bits = bits
-	sector, ok := SectorType[fields[2]]
+	sector, ok := d.Sectors[fields[2]]
 	if !ok {
 		return nil, fmt.Errorf("unknown room sector type: %q", fields[2])
 	}
 	r.Sector = sector
+	r.SectorRaw = fields[2]
 	for {
 		// optional stuff
 		if err := scanner.MustScan(); err != nil {
@@ -185,7 +368,7 @@ func scanRoom(scanner *fileScanner) (*Room, error) {
 			// end of room
 			return &r, nil
 		case strings.HasPrefix(s, "D"):
-			dir, err := scanDir(scanner)
+			dir, err := scanDir(scanner, d)
 			if err != nil {
 				return nil, err
 			}
@@ -196,21 +379,29 @@ func scanRoom(scanner *fileScanner) (*Room, error) {
 				return nil, err
 			}
 			r.Extras = append(r.Extras, *ex)
+		case strings.HasPrefix(s, "T "):
+			trig, err := scanTriggerAttachment(s)
+			if err != nil {
+				return nil, err
+			}
+			r.Triggers = append(r.Triggers, trig)
 		default:
 			return nil, fmt.Errorf("unexpected token in room definition: %q", s)
 		}
 	}
 }
 
-func scanDir(scanner *fileScanner) (*Exit, error) {
+func scanDir(scanner *fileScanner, d *Dialect) (*Exit, error) {
 	// previous code checked that the first character was a D so we can ignore that.
 	s := strings.TrimSpace(scanner.Text()[1:])
-	dir, ok := ExitDir[s]
+	dir, ok := d.Directions[s]
 	if !ok {
 		return nil, fmt.Errorf("unknown exit direction %q", s)
 	}
 	ex := &Exit{
-		Direction: dir,
+		Direction:    dir,
+		Opposite:     d.Opposites[dir],
+		DirectionRaw: s,
 	}
 	desc, err := scanner.ScanUntil("~")
 	if err != nil {
@@ -222,7 +413,7 @@ func scanDir(scanner *fileScanner) (*Exit, error) {
 	}
 	keywords := scanner.Text()
 	if !strings.HasSuffix(keywords, "~") {
-		return nil, fmt.Errorf("expected keyword list to end in ~ but got %q", keywords)
+		return nil, expectedError("keyword list to end in ~", keywords)
 	}
 	ex.Keywords = strings.Fields(keywords[:len(keywords)-1])
 	if err := scanner.MustScan(); err != nil {
@@ -230,13 +421,14 @@ func scanDir(scanner *fileScanner) (*Exit, error) {
 	}
 	fields := strings.Fields(scanner.Text())
 	if len(fields) != 3 {
-		return nil, fmt.Errorf("expected direction fields to be <door_flag> <key_number> <room_linked> but got %q", scanner.Text())
+		return nil, expectedError("direction fields to be <door_flag> <key_number> <room_linked>", scanner.Text())
 	}
-	flag, ok := DoorFlags[fields[0]]
+	flag, ok := d.DoorFlags[fields[0]]
 	if !ok {
 		return nil, fmt.Errorf("unknown door flag %q", fields[0])
 	}
 	ex.DoorFlag = flag
+	ex.DoorFlagRaw = fields[0]
 	num, err := strconv.Atoi(fields[1])
 	if err != nil {
 		return nil, fmt.Errorf("invalid key number: %q", fields[1])
@@ -256,7 +448,7 @@ func scanExtra(scanner *fileScanner) (*ExtraDesc, error) {
 	}
 	s := scanner.Text()
 	if !strings.HasSuffix(s, "~") {
-		return nil, fmt.Errorf("expected extra description keywords to end in ~, but got %q", s)
+		return nil, expectedError("extra description keywords to end in ~", s)
 	}
 	keywords := strings.Fields(s[:len(s)-1])
 	ex := &ExtraDesc{
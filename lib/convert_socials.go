@@ -0,0 +1,209 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConvertSocials converts all the CircleMUD socials files in the from
+// directory that match the pattern to json files in the to directory.
+func ConvertSocials(to, from, pattern string) error {
+	files, err := filepath.Glob(filepath.Join(from, pattern))
+	if err != nil {
+		return err
+	}
+	return ConvertSocialFiles(to, files, Options{})
+}
+
+// ConvertSocialFiles converts the given CircleMUD socials files to json files
+// in the to directory.
+func ConvertSocialFiles(to string, files []string, opts Options) error {
+	if err := makeOutputDir(to); err != nil {
+		return err
+	}
+	for _, name := range files {
+		f, n, _, err := readInput(name, opts.Encoding)
+		if err != nil {
+			return err
+		}
+		socials, err := ParseSocials(f, n)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(map[string]interface{}{"socials": socials}, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to convert %q to json: %v", name, err)
+		}
+		if err := writeOutput(to, name, ".json", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Social is a social command (like smile or bow) and the messages it sends.
+// A message that is # in the file (meaning there is no message) is null,
+// which is not the same as an empty message.
+type Social struct {
+	Command           string `json:"command"`
+	SortAs            string `json:"sort_as,omitempty"`
+	Hide              bool   `json:"hide"`
+	MinCharPosition   string `json:"min_char_position,omitempty"`
+	MinVictimPosition string `json:"min_victim_position"`
+	MinLevel          int    `json:"min_level,omitempty"`
+
+	CharNoArg   *string `json:"char_no_arg"`
+	OthersNoArg *string `json:"others_no_arg"`
+	CharFound   *string `json:"char_found"`
+	OthersFound *string `json:"others_found"`
+	VictFound   *string `json:"vict_found"`
+	NotFound    *string `json:"not_found"`
+	CharAuto    *string `json:"char_auto"`
+	OthersAuto  *string `json:"others_auto"`
+
+	// tbaMUD (socials.new) messages for socials done to a victim's body part
+	// or to an object.
+	CharBodyFound   *string `json:"char_body_found"`
+	OthersBodyFound *string `json:"others_body_found"`
+	VictBodyFound   *string `json:"vict_body_found"`
+	CharObjFound    *string `json:"char_obj_found"`
+	OthersObjFound  *string `json:"others_obj_found"`
+}
+
+// ParseSocialsFile parses the given CircleMUD socials file.  Both the
+// CircleMUD format and the tbaMUD socials.new format (whose commands start
+// with ~) are supported.
+func ParseSocialsFile(filename string) ([]*Social, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseSocials(f, filename)
+}
+
+// ParseSocials parses socials from a socials file read from r.  The name is
+// used in error messages.
+func ParseSocials(r io.Reader, name string) (_ []*Social, err error) {
+	// need this because scan can panic if you send it too much stuff
+	defer func() {
+		panicErr := recover()
+		if panicErr == nil {
+			return
+		}
+		if e, ok := panicErr.(error); ok {
+			err = e
+			return
+		}
+		err = fmt.Errorf("%v", panicErr)
+	}()
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	defer func() {
+		if err != nil {
+			// add where in the file the error happened
+			err = scanner.parseError(name, err)
+		}
+	}()
+	socials := []*Social{}
+	for {
+		if err := scanner.MustScan(); err != nil {
+			return nil, err
+		}
+		s := strings.TrimSpace(scanner.Text())
+		if s == "" {
+			continue
+		}
+		if s == "$" {
+			return socials, nil
+		}
+		social, err := scanSocial(scanner)
+		if err != nil {
+			return nil, err
+		}
+		socials = append(socials, social)
+	}
+}
+
+func scanSocial(scanner *fileScanner) (*Social, error) {
+	s := strings.TrimSpace(scanner.Text())
+	newFormat := strings.HasPrefix(s, "~")
+	fields := strings.Fields(strings.TrimPrefix(s, "~"))
+	var soc Social
+	if newFormat {
+		if len(fields) != 6 {
+			return nil, expectedError("social to be ~<command> <sort as> <hide flag> <min char position> <min victim position> <min level>", s)
+		}
+		soc.SortAs = fields[1]
+		pos, ok := PositionNames[fields[3]]
+		if !ok {
+			return nil, fmt.Errorf("unknown position: %s", fields[3])
+		}
+		soc.MinCharPosition = pos
+		level, err := strconv.Atoi(fields[5])
+		if err != nil {
+			return nil, fmt.Errorf("invalid minimum level: %q", fields[5])
+		}
+		soc.MinLevel = level
+		fields = []string{fields[0], fields[2], fields[4]}
+	} else if len(fields) != 3 {
+		return nil, expectedError("social to be <command> <hide flag> <min victim position>", s)
+	}
+	soc.Command = fields[0]
+	hide, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return nil, fmt.Errorf("invalid hide flag: %q", fields[1])
+	}
+	soc.Hide = hide != 0
+	pos, ok := PositionNames[fields[2]]
+	if !ok {
+		return nil, fmt.Errorf("unknown position: %s", fields[2])
+	}
+	soc.MinVictimPosition = pos
+
+	messages := []**string{&soc.CharNoArg, &soc.OthersNoArg, &soc.CharFound}
+	for _, m := range messages {
+		if *m, err = scanActionMessage(scanner); err != nil {
+			return nil, err
+		}
+	}
+	// in the old format, if there's no message for finding a victim, the rest
+	// of the messages are left out.
+	if !newFormat && soc.CharFound == nil {
+		return &soc, nil
+	}
+	messages = []**string{&soc.OthersFound, &soc.VictFound, &soc.NotFound, &soc.CharAuto, &soc.OthersAuto}
+	if newFormat {
+		messages = append(messages, &soc.CharBodyFound, &soc.OthersBodyFound, &soc.VictBodyFound, &soc.CharObjFound, &soc.OthersObjFound)
+	}
+	for _, m := range messages {
+		if *m, err = scanActionMessage(scanner); err != nil {
+			return nil, err
+		}
+	}
+	return &soc, nil
+}
+
+// scanActionMessage reads a single social or combat message.  A message of #
+// means there is no message, and is returned as nil.
+func scanActionMessage(scanner *fileScanner) (*string, error) {
+	if err := scanner.MustScan(); err != nil {
+		return nil, err
+	}
+	s := scanner.Text()
+	if strings.HasPrefix(s, "#") {
+		return nil, nil
+	}
+	return &s, nil
+}
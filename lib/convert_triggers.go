@@ -0,0 +1,188 @@
+package lib
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ConvertTriggers converts all the DG Scripts trigger files in the from directory
+// that match the pattern to json files in the to directory.
+func ConvertTriggers(to, from, pattern string) error {
+	files, err := filepath.Glob(filepath.Join(from, pattern))
+	if err != nil {
+		return err
+	}
+	return ConvertTriggerFiles(to, files, Options{})
+}
+
+// ConvertTriggerFiles converts the given DG Scripts trigger files to json files in the to
+// directory.
+func ConvertTriggerFiles(to string, files []string, opts Options) error {
+	if err := makeOutputDir(to); err != nil {
+		return err
+	}
+	for _, name := range files {
+		f, n, _, err := readInput(name, opts.Encoding)
+		if err != nil {
+			return err
+		}
+		trigs, err := ParseTriggers(f, n)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		b, err := json.MarshalIndent(map[string]interface{}{"triggers": trigs}, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to convert %q to json: %v", name, err)
+		}
+		if err := writeOutput(to, name, ".json", b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Trigger is a DG Script that can be attached to a mob, object, or room.
+type Trigger struct {
+	Number     int      `json:"number"`
+	Name       string   `json:"name"`
+	AttachType string   `json:"attach_type"`
+	Types      []string `json:"types"`
+	NumArg     int      `json:"numeric_arg"`
+	Arg        string   `json:"arg"`
+	Script     string   `json:"script"`
+}
+
+// ParseTrgFile parses the given DG Scripts trigger file.
+func ParseTrgFile(filename string) ([]*Trigger, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseTriggers(f, filename)
+}
+
+// ParseTriggers parses triggers from a trg file read from r.  The name is used
+// in error messages.
+func ParseTriggers(r io.Reader, name string) (_ []*Trigger, err error) {
+	// need this because scan can panic if you send it too much stuff
+	defer func() {
+		panicErr := recover()
+		if panicErr == nil {
+			return
+		}
+		if e, ok := panicErr.(error); ok {
+			err = e
+			return
+		}
+		err = fmt.Errorf("%v", panicErr)
+	}()
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	defer func() {
+		if err != nil {
+			// add where in the file the error happened
+			err = scanner.parseError(name, err)
+		}
+	}()
+	trigs := []*Trigger{}
+	for {
+		if !scanner.Scan() {
+			if err = scanner.Err(); err != nil {
+				return nil, err
+			}
+			return trigs, nil
+		}
+		if strings.TrimSpace(scanner.Text()) == "$" {
+			return trigs, nil
+		}
+		trig, err := scanTrigger(scanner)
+		if err != nil {
+			return nil, err
+		}
+		trigs = append(trigs, trig)
+	}
+}
+
+func scanTrigger(scanner *fileScanner) (*Trigger, error) {
+	scanner.endRecord()
+	number := strings.TrimSpace(scanner.Text())
+	if !strings.HasPrefix(number, "#") {
+		return nil, expectedError("trigger number to start with #", number)
+	}
+	num, err := strconv.Atoi(number[1:])
+	if err != nil {
+		return nil, fmt.Errorf("trigger number %q not a number: %v", number[1:], err)
+	}
+	t := Trigger{Number: num}
+	scanner.startRecord(num)
+
+	name, err := scanner.ScanUntil("~")
+	if err != nil {
+		return nil, err
+	}
+	t.Name = name
+
+	if err := scanner.MustScan(); err != nil {
+		return nil, err
+	}
+	fields := strings.Fields(scanner.Text())
+	if len(fields) != 3 {
+		return nil, expectedError("trigger metadata to be <attach type> <trigger type bitvector> <numeric arg>", scanner.Text())
+	}
+	attach, ok := TriggerAttachTypes[fields[0]]
+	if !ok {
+		return nil, fmt.Errorf("unknown trigger attach type: %q", fields[0])
+	}
+	t.AttachType = attach
+
+	types, err := TriggerTypesToNames(attach, fields[1])
+	if err != nil {
+		return nil, err
+	}
+	t.Types = types
+
+	narg, err := strconv.Atoi(fields[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid numeric arg: %q", fields[2])
+	}
+	t.NumArg = narg
+
+	arg, err := scanner.ScanUntil("~")
+	if err != nil {
+		return nil, err
+	}
+	t.Arg = arg
+
+	script, err := scanner.ScanUntil("~")
+	if err != nil {
+		return nil, err
+	}
+	t.Script = script
+	return &t, nil
+}
+
+// scanTriggerAttachment parses a T <trigger vnum> line from a room, mob, or
+// object definition.
+func scanTriggerAttachment(s string) (int, error) {
+	fields := strings.Fields(s)
+	if len(fields) != 2 || fields[0] != "T" {
+		return 0, expectedError("trigger attachment to be T <trigger vnum>", s)
+	}
+	num, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, fmt.Errorf("invalid trigger number: %q", fields[1])
+	}
+	return num, nil
+}
@@ -2,9 +2,8 @@ package lib
 
 import (
 	"bufio"
-	"encoding/json"
 	"fmt"
-	"io/ioutil"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -13,37 +12,103 @@ import (
 
 // ConvertZones converts all the CircleMUD zone files in the from directory
 // that match the pattern to json files in the to directory.
-func ConvertZones(to, from, pattern string) (err error) {
-	if err := os.MkdirAll(to, 0700); err != nil {
-		return fmt.Errorf("couldn't create output directory: %v", err)
-	}
+func ConvertZones(to, from, pattern string) error {
 	files, err := filepath.Glob(filepath.Join(from, pattern))
 	if err != nil {
 		return err
 	}
-	for _, name := range files {
-		zone, err := ParseZoneFile(name)
+	return ConvertZoneFiles(to, files, Options{})
+}
+
+// ConvertZoneFiles converts the given CircleMUD zone files to json files in the to
+// directory.
+func ConvertZoneFiles(to string, files []string, opts Options) error {
+	return convertFiles(to, files, opts, convertZoneFile)
+}
+
+// convertZoneFile converts a single zone file.  It's a fileConverter.
+func convertZoneFile(to, name string, opts Options) (n string, perrs []error, out *output, err error) {
+	f, n, enc, err := readInput(name, opts.Encoding)
+	if err != nil {
+		return name, []error{err}, nil, nil
+	}
+	defer f.Close()
+	o, err := createOutput(to, name, ".json")
+	if err != nil {
+		return n, nil, nil, err
+	}
+	defer func() {
 		if err != nil {
-			return err
+			o.discard()
+		}
+	}()
+	w, err := newRecordWriter(o, "zones")
+	if err != nil {
+		return n, nil, nil, err
+	}
+	zr := opts.Dialect.NewZoneReader(f, n)
+	for {
+		z, err := zr.Next()
+		if err == io.EOF {
+			break
 		}
-		b, err := json.MarshalIndent(zone, "", "    ")
 		if err != nil {
-			return fmt.Errorf("failed to convert %q to json: %v", name, err)
+			perrs = append(perrs, err)
+			if !opts.KeepGoing {
+				break
+			}
+			continue
 		}
-		n := filepath.Base(name)
-		ext := filepath.Ext(n)
-		n = n[:len(n)-len(ext)] + ".json"
-		n = filepath.Join(to, n)
-
-		if err := ioutil.WriteFile(n, b, 0600); err != nil {
-			return err
+		if !opts.Lossless {
+			z.ResetModeRaw = ""
 		}
+		if err := w.Write(z); err != nil {
+			return n, perrs, nil, fmt.Errorf("failed to convert %q to json: %v", name, err)
+		}
+	}
+	err = w.Close(
+		jsonField{"line_ending", lineEnding(zr.CRLF())},
+		jsonField{"encoding", encodingName(enc)},
+	)
+	if err == nil {
+		err = o.close()
+	}
+	if err != nil {
+		return n, perrs, nil, err
+	}
+	if len(perrs) > 0 && (!opts.WritePartial || w.count == 0) {
+		o.discard()
+		return n, perrs, nil, nil
 	}
-	return nil
+	return n, perrs, o, nil
 }
 
 // ParseZoneFile parses the given CircleMUD zone file.
-func ParseZoneFile(filename string) (zone *Zone, err error) {
+func ParseZoneFile(filename string) (*Zone, error) {
+	return (*Dialect)(nil).ParseZoneFile(filename)
+}
+
+// ParseZone parses a zone file read from r.  The name is used in error
+// messages.
+func ParseZone(r io.Reader, name string) (*Zone, error) {
+	return (*Dialect)(nil).ParseZone(r, name)
+}
+
+// ParseZoneFile parses the given zone file using the tables from the dialect.
+// If d is nil, CircleMUD 3.0's tables are used.
+func (d *Dialect) ParseZoneFile(filename string) (*Zone, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return d.ParseZone(f, filename)
+}
+
+// ParseZone parses a zone file read from r using the tables from the dialect.
+// The name is used in error messages.  If d is nil, CircleMUD 3.0's tables are
+// used.
+func (d *Dialect) ParseZone(r io.Reader, name string) (zone *Zone, err error) {
 	// need this because scan can panic if you send it too much stuff
 	defer func() {
 		panicErr := recover()
@@ -57,21 +122,16 @@ func ParseZoneFile(filename string) (zone *Zone, err error) {
 		err = fmt.Errorf("%v", panicErr)
 	}()
 
-	f, err := os.Open(filename)
-	if err != nil {
-		return nil, err
-	}
-	defer f.Close()
-
 	line := 0
 	scanner := &fileScanner{
 		line:    &line,
-		Scanner: bufio.NewScanner(f),
+		Scanner: bufio.NewScanner(r),
+		dialect: d,
 	}
 	defer func() {
 		if err != nil {
-			// add filename and line number to error
-			err = fmt.Errorf("%s:%v - %s", filename, line, err)
+			// add where in the file the error happened
+			err = scanner.parseError(name, err)
 		}
 	}()
 	if err := scanner.MustScan(); err != nil {
@@ -90,13 +150,127 @@ func ParseZoneFile(filename string) (zone *Zone, err error) {
 	return zone, nil
 }
 
+// ZoneReader reads the zones from a file one at a time.  Zone files have a
+// single zone, but ZoneReader also reads files with any number of zones one
+// after another, such as a merged world file.
+type ZoneReader struct {
+	scanner *fileScanner
+	name    string
+	line    int
+	// start is the line the last zone started on, and failed is set if it
+	// couldn't be parsed.
+	start  int
+	failed bool
+	done   bool
+}
+
+// NewZoneReader returns a ZoneReader that reads zones from r.  The name is
+// used in error messages.
+func NewZoneReader(r io.Reader, name string) *ZoneReader {
+	return (*Dialect)(nil).NewZoneReader(r, name)
+}
+
+// NewZoneReader returns a ZoneReader that reads zones from r using the tables
+// from the dialect.  The name is used in error messages.  If d is nil,
+// CircleMUD 3.0's tables are used.
+func (d *Dialect) NewZoneReader(r io.Reader, name string) *ZoneReader {
+	zr := &ZoneReader{name: name}
+	zr.scanner = &fileScanner{
+		line:    &zr.line,
+		Scanner: bufio.NewScanner(r),
+		dialect: d,
+	}
+	return zr
+}
+
+// Next returns the next zone, or io.EOF after the last one.  If a zone can't
+// be parsed, Next returns a *ParseError, and calling Next again skips to the
+// zone after it.
+func (zr *ZoneReader) Next() (zone *Zone, err error) {
+	if zr.done {
+		return nil, io.EOF
+	}
+	// need this because scan can panic if you send it too much stuff
+	defer func() {
+		panicErr := recover()
+		if panicErr == nil {
+			return
+		}
+		zr.done = true
+		e, ok := panicErr.(error)
+		if !ok {
+			e = fmt.Errorf("%v", panicErr)
+		}
+		err = zr.scanner.parseError(zr.name, e)
+	}()
+
+	scanner := zr.scanner
+	if zr.failed {
+		zr.failed = false
+		if !scanner.skipToRecord(zr.start) {
+			zr.done = true
+			return nil, io.EOF
+		}
+	} else if !scanner.Scan() {
+		zr.done = true
+		if err := scanner.Err(); err != nil {
+			return nil, scanner.parseError(zr.name, err)
+		}
+		return nil, io.EOF
+	}
+	if strings.TrimSpace(scanner.Text()) == "$" {
+		zr.done = true
+		return nil, io.EOF
+	}
+	zr.start = zr.line
+	zone, err = scanZone(scanner)
+	if err != nil {
+		// add where in the file the error happened
+		zr.failed = true
+		return nil, scanner.parseError(zr.name, err)
+	}
+	return zone, nil
+}
+
+// CRLF reports whether most of the lines read so far ended in \r\n.
+func (zr *ZoneReader) CRLF() bool {
+	return zr.scanner.usesCRLF()
+}
+
 type Zone struct {
-	Number       int    `json:"number"`
-	Name         string `json:"name"`
-	BottomNumber int    `json:"bottom_number"`
-	TopNumber    int    `json:"top_number"`
-	LifespanMins int    `json:"lifespan_minutes"`
-	ResetMode    string `json:"reset_mode"`
+	Number       int           `json:"number"`
+	Name         string        `json:"name"`
+	BottomNumber int           `json:"bottom_number"`
+	TopNumber    int           `json:"top_number"`
+	LifespanMins int           `json:"lifespan_minutes"`
+	ResetMode    string        `json:"reset_mode"`
+	Commands     []ZoneCommand `json:"commands"`
+	ResetModeRaw string        `json:"reset_mode_raw,omitempty"`
+}
+
+// ZoneCommand is a single reset command from a zone file.  The meaning of each
+// argument depends on the command, so the interpreted arguments are only set
+// for the commands that use them.
+type ZoneCommand struct {
+	Command     string `json:"command"`
+	Name        string `json:"name"`
+	IfFlag      bool   `json:"if_flag"`
+	Args        []int  `json:"args"`
+	Mob         *int   `json:"mob,omitempty"`
+	Object      *int   `json:"object,omitempty"`
+	Container   *int   `json:"container,omitempty"`
+	Room        *int   `json:"room,omitempty"`
+	MaxExisting *int   `json:"max_existing,omitempty"`
+	Position    string `json:"position,omitempty"`
+	Direction   string `json:"direction,omitempty"`
+	DoorState   string `json:"door_state,omitempty"`
+	AttachType  string `json:"attach_type,omitempty"`
+	Trigger     *int   `json:"trigger,omitempty"`
+	Context     *int   `json:"context,omitempty"`
+	Variable    string `json:"variable,omitempty"`
+	Value       string `json:"value,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+	Line        int    `json:"line"`
 }
 
 const (
@@ -112,15 +286,17 @@ var resetMap = map[string]string{
 }
 
 func scanZone(scanner *fileScanner) (*Zone, error) {
+	scanner.endRecord()
 	number := strings.TrimSpace(scanner.Text())
 	if !strings.HasPrefix(number, "#") {
-		return nil, fmt.Errorf("zone number must start with #, but found: %q", number)
+		return nil, expectedError("zone number to start with #", number)
 	}
 	num, err := strconv.Atoi(number[1:])
 	if err != nil {
 		return nil, fmt.Errorf("zone number %q not a number: %v", number[1:], err)
 	}
 	z := Zone{Number: num}
+	scanner.startRecord(num)
 	name, err := scanner.ScanUntil("~")
 	if err != nil {
 		return nil, err
@@ -131,7 +307,7 @@ func scanZone(scanner *fileScanner) (*Zone, error) {
 	}
 	fields := strings.Fields(scanner.Text())
 	if len(fields) != 4 {
-		return nil, fmt.Errorf("expected zone metadata to be <bottom_room#> <top_room#> <lifespan> <reset_mode>, but got %q", scanner.Text())
+		return nil, expectedError("zone metadata to be <bottom_room#> <top_room#> <lifespan> <reset_mode>", scanner.Text())
 	}
 
 	bottomRoom, err := strconv.Atoi(fields[0])
@@ -155,11 +331,170 @@ func scanZone(scanner *fileScanner) (*Zone, error) {
 		return nil, fmt.Errorf("unknown reset mode: %q", fields[3])
 	}
 	z.ResetMode = mode
+	z.ResetModeRaw = fields[3]
+
+	for {
+		if err := scanner.MustScan(); err != nil {
+			return nil, err
+		}
+		s := strings.TrimSpace(scanner.Text())
+		switch {
+		case s == "S":
+			// end of zone
+			return &z, nil
+		case s == "", strings.HasPrefix(s, "*"):
+			// comment or blank line
+		default:
+			cmd, err := scanZoneCommand(scanner)
+			if err != nil {
+				return nil, err
+			}
+			z.Commands = append(z.Commands, *cmd)
+		}
+	}
+}
 
-	// skip all mobs and zone commands for now.
-	_, err = scanner.ScanUntil("S")
+// zoneCommandArgs is the number of arguments each zone command takes after
+// the if-flag.
+var zoneCommandArgs = map[string]int{
+	"M": 3, // <mob vnum> <max existing> <room vnum>
+	"O": 3, // <obj vnum> <max existing> <room vnum>
+	"G": 2, // <obj vnum> <max existing>
+	"E": 3, // <obj vnum> <max existing> <equipment position>
+	"P": 3, // <obj vnum 1> <max existing> <obj vnum 2>
+	"D": 3, // <room vnum> <exit num> <state>
+	"R": 2, // <room vnum> <obj vnum>
+	"T": 3, // <attach type> <trigger vnum> <room vnum>
+	"V": 4, // <attach type> <context> <room vnum> <variable>, then the value
+}
+
+func scanZoneCommand(scanner *fileScanner) (*ZoneCommand, error) {
+	s := strings.TrimSpace(scanner.Text())
+	command := s[:1]
+	name, ok := ZoneCommandNames[command]
+	if !ok {
+		// some MUDs have commands of their own, which are kept as they are.
+		return rawZoneCommand(command, s[1:], *scanner.line), nil
+	}
+	numArgs := zoneCommandArgs[command]
+	fields, comment := splitFields(s[1:], numArgs+1)
+	if len(fields) != numArgs+1 {
+		return nil, expectedError(fmt.Sprintf("zone command %s to have an if-flag and %d arguments", command, numArgs), s)
+	}
+	c := ZoneCommand{
+		Command: command,
+		Name:    name,
+		Comment: comment,
+		Line:    *scanner.line,
+	}
+	ifFlag, err := strconv.Atoi(fields[0])
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("invalid if-flag: %q", fields[0])
+	}
+	c.IfFlag = ifFlag != 0
+	if command == "V" {
+		// the variable is a name, and the rest of the line is its value.
+		c.Variable, c.Value, c.Comment = fields[4], comment, ""
+		fields = fields[:4]
+	}
+	for _, f := range fields[1:] {
+		arg, err := strconv.Atoi(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid zone command argument: %q", f)
+		}
+		c.Args = append(c.Args, arg)
+	}
+
+	args := c.Args
+	switch command {
+	case "M":
+		c.Mob, c.MaxExisting, c.Room = intp(args[0]), intp(args[1]), intp(args[2])
+	case "O":
+		c.Object, c.MaxExisting, c.Room = intp(args[0]), intp(args[1]), intp(args[2])
+	case "G":
+		c.Object, c.MaxExisting = intp(args[0]), intp(args[1])
+	case "E":
+		c.Object, c.MaxExisting = intp(args[0]), intp(args[1])
+		pos, ok := WearPositions[fields[3]]
+		if !ok {
+			return nil, fmt.Errorf("unknown equipment position: %q", fields[3])
+		}
+		c.Position = pos
+	case "P":
+		c.Object, c.MaxExisting, c.Container = intp(args[0]), intp(args[1]), intp(args[2])
+	case "D":
+		c.Room = intp(args[0])
+		dir, ok := scanner.dialect.forFormat(false).Directions[fields[2]]
+		if !ok {
+			return nil, fmt.Errorf("unknown exit direction %q", fields[2])
+		}
+		c.Direction = dir
+		state, ok := DoorStates[fields[3]]
+		if !ok {
+			return nil, fmt.Errorf("unknown door state: %q", fields[3])
+		}
+		c.DoorState = state
+	case "R":
+		c.Room, c.Object = intp(args[0]), intp(args[1])
+	case "T", "V":
+		c.AttachType = TriggerAttachTypes[fields[1]]
+		if command == "T" {
+			c.Trigger = intp(args[1])
+		} else {
+			c.Context = intp(args[1])
+		}
+		if c.AttachType == WORLD_TRIGGER {
+			c.Room = intp(args[2])
+		}
+	}
+	return &c, nil
+}
+
+// rawZoneCommand returns a zone command that isn't one of the known ones.  The
+// numbers at the start of its arguments are taken to be its if-flag and
+// arguments, and anything after them is kept as its comment.
+func rawZoneCommand(command, s string, line int) *ZoneCommand {
+	c := &ZoneCommand{Command: command, Line: line}
+	rest := strings.TrimSpace(s)
+	for i := 0; ; i++ {
+		fields, r := splitFields(rest, 1)
+		if len(fields) == 0 {
+			break
+		}
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			break
+		}
+		if i == 0 {
+			c.IfFlag = n != 0
+		} else {
+			c.Args = append(c.Args, n)
+		}
+		rest = r
 	}
-	return &z, nil
+	c.Comment = rest
+	return c
+}
+
+// splitFields returns up to n whitespace-separated fields from the start of s,
+// and whatever is left over after them, trimmed of surrounding whitespace.
+func splitFields(s string, n int) (fields []string, rest string) {
+	rest = s
+	for len(fields) < n {
+		rest = strings.TrimLeft(rest, " \t")
+		if rest == "" {
+			break
+		}
+		end := strings.IndexAny(rest, " \t")
+		if end == -1 {
+			end = len(rest)
+		}
+		fields = append(fields, rest[:end])
+		rest = rest[end:]
+	}
+	return fields, strings.TrimSpace(rest)
+}
+
+func intp(i int) *int {
+	return &i
 }
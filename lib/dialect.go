@@ -0,0 +1,189 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+)
+
+// Dialect holds the tables that differ between CircleMUD and the MUDs derived
+// from it.  Flags are listed by bit number, so the first flag is bit 0, which
+// is the letter a.
+type Dialect struct {
+	Name string `json:"name,omitempty"`
+	// Base is the name of the built-in dialect a dialect file starts from.
+	// Tables of names by number are added to the base's tables, and lists of
+	// flags replace the base's.
+	Base string `json:"base,omitempty"`
+	// WideFlags means the MUD writes tbaMUD style 128 bit flags.
+	WideFlags bool `json:"wide_flags"`
+
+	Sectors    map[string]string `json:"sectors"`
+	Directions map[string]string `json:"directions"`
+	// Opposites is the direction that leads back the way an exit came, by
+	// direction name.
+	Opposites   map[string]string `json:"opposites"`
+	DoorFlags   map[string]string `json:"door_flags"`
+	RoomFlags   []string          `json:"room_flags"`
+	MobFlags    []string          `json:"mob_flags"`
+	AffectFlags []string          `json:"affect_flags"`
+	Positions   map[string]string `json:"positions"`
+	Genders     map[string]string `json:"genders"`
+}
+
+// Circle30 is stock CircleMUD 3.0.
+var Circle30 = &Dialect{
+	Name:        "circle30",
+	Sectors:     SectorType,
+	Directions:  ExitDir,
+	Opposites:   OppositeDir,
+	DoorFlags:   DoorFlags,
+	RoomFlags:   charsToFlags(RoomChars),
+	MobFlags:    charsToFlags(MobActionChars),
+	AffectFlags: charsToFlags(MobAffectionChars),
+	Positions:   PositionNames,
+	Genders:     genders,
+}
+
+// Circle31 is stock CircleMUD 3.1.
+var Circle31 = &Dialect{
+	Name:        "circle31",
+	Sectors:     SectorType,
+	Directions:  ExitDir,
+	Opposites:   OppositeDir,
+	DoorFlags:   DoorFlags,
+	RoomFlags:   charsToFlags(RoomChars),
+	MobFlags:    append(charsToFlags(MobActionChars), "NOTDEADYET"),
+	AffectFlags: charsToFlags(MobAffectionChars),
+	Positions:   PositionNames,
+	Genders:     genders,
+}
+
+// TbaMUD is stock tbaMUD.
+var TbaMUD = &Dialect{
+	Name:      "tbamud",
+	WideFlags: true,
+	Sectors: map[string]string{
+		"0": "INSIDE",
+		"1": "CITY",
+		"2": "FIELD",
+		"3": "FOREST",
+		"4": "HILLS",
+		"5": "MOUNTAIN",
+		"6": "WATER_SWIM",
+		"7": "WATER_NOSWIM",
+		"8": "FLYING",
+		"9": "UNDERWATER",
+	},
+	Directions:  ExitDir,
+	Opposites:   OppositeDir,
+	DoorFlags:   DoorFlags,
+	RoomFlags:   TbaRoomFlags,
+	MobFlags:    TbaMobFlags,
+	AffectFlags: TbaAffectFlags,
+	Positions:   PositionNames,
+	Genders:     genders,
+}
+
+// Dialects are the built-in dialects, by name.
+var Dialects = map[string]*Dialect{
+	Circle30.Name: Circle30,
+	Circle31.Name: Circle31,
+	TbaMUD.Name:   TbaMUD,
+}
+
+// LoadDialect reads a dialect from a json file.
+func LoadDialect(filename string) (*Dialect, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	var base struct {
+		Base string `json:"base"`
+	}
+	if err := json.Unmarshal(b, &base); err != nil {
+		return nil, fmt.Errorf("failed to read dialect %q: %v", filename, err)
+	}
+	d := &Dialect{Opposites: copyTable(OppositeDir)}
+	if base.Base != "" {
+		bd, ok := Dialects[base.Base]
+		if !ok {
+			return nil, fmt.Errorf("dialect %q has unknown base dialect %q", filename, base.Base)
+		}
+		d = bd.copy()
+	}
+	if err := json.Unmarshal(b, d); err != nil {
+		return nil, fmt.Errorf("failed to read dialect %q: %v", filename, err)
+	}
+	if d.Name == "" {
+		d.Name = filename
+	}
+	return d, nil
+}
+
+// copy returns a deep copy of the dialect, so that a dialect file can add to
+// it without changing the original.
+func (d *Dialect) copy() *Dialect {
+	c := *d
+	c.Sectors = copyTable(d.Sectors)
+	c.Directions = copyTable(d.Directions)
+	c.Opposites = copyTable(d.Opposites)
+	c.DoorFlags = copyTable(d.DoorFlags)
+	c.Positions = copyTable(d.Positions)
+	c.Genders = copyTable(d.Genders)
+	c.RoomFlags = append([]string(nil), d.RoomFlags...)
+	c.MobFlags = append([]string(nil), d.MobFlags...)
+	c.AffectFlags = append([]string(nil), d.AffectFlags...)
+	return &c
+}
+
+func copyTable(table map[string]string) map[string]string {
+	c := make(map[string]string, len(table))
+	for k, v := range table {
+		c[k] = v
+	}
+	return c
+}
+
+// forFormat returns the dialect to use for a record.  If no dialect was
+// chosen (d is nil), records with tbaMUD's 128 bit flags are read as tbaMUD,
+// and everything else as CircleMUD 3.0.
+func (d *Dialect) forFormat(wide bool) *Dialect {
+	if d != nil {
+		return d
+	}
+	if wide {
+		return TbaMUD
+	}
+	return Circle30
+}
+
+// dialectName returns the dialect to write to the json for a file, so that it
+// can be written back the same way.  Files read with a dialect that was chosen
+// by the user leave it out.
+func dialectName(d *Dialect) string {
+	if d == nil {
+		return ""
+	}
+	return d.Name
+}
+
+// charsToFlags converts a table of bit letters into a list of flags by bit
+// number.
+func charsToFlags(chars map[rune]string) []string {
+	var runes []rune
+	for r := range chars {
+		runes = append(runes, r)
+	}
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+	var flags []string
+	for _, r := range runes {
+		n, _ := letterToBit(r)
+		for uint(len(flags)) < n {
+			flags = append(flags, "")
+		}
+		flags = append(flags, chars[r])
+	}
+	return flags
+}
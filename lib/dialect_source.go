@@ -0,0 +1,229 @@
+package lib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// sourceDirections are the names of the direction #defines in structs.h.
+var sourceDirections = map[string]string{
+	"NORTH":     "North",
+	"EAST":      "East",
+	"SOUTH":     "South",
+	"WEST":      "West",
+	"UP":        "Up",
+	"DOWN":      "Down",
+	"NORTHWEST": "Northwest",
+	"NORTHEAST": "Northeast",
+	"SOUTHEAST": "Southeast",
+	"SOUTHWEST": "Southwest",
+	"IN":        "In",
+	"OUT":       "Out",
+}
+
+// DialectFromSource builds a dialect from the #defines in a MUD's structs.h,
+// such as ROOM_DARK (1 << 0), SECT_INSIDE 0 and POS_DEAD 0.  If the flags are
+// defined as bit numbers rather than shifts, the MUD is taken to use tbaMUD
+// style 128 bit flags.  Tables that aren't defined in the file are copied from
+// circle30 or tbamud.
+func DialectFromSource(filename string) (*Dialect, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	d, err := readDialectSource(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s - %v", filename, err)
+	}
+	return d, nil
+}
+
+// sourceDefine is a #define with a numeric value.
+type sourceDefine struct {
+	name  string
+	value uint64
+	shift bool
+	line  int
+}
+
+func readDialectSource(r io.Reader) (*Dialect, error) {
+	prefixes := []string{"ROOM_", "MOB_", "AFF_", "SECT_", "POS_", "SEX_"}
+	defines := map[string][]sourceDefine{}
+	var dirs []sourceDefine
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		name, def, ok := parseDefine(scanner.Text())
+		if !ok {
+			continue
+		}
+		def.line = line
+		if _, ok := sourceDirections[name]; ok && !def.shift {
+			def.name = sourceDirections[name]
+			dirs = append(dirs, def)
+			continue
+		}
+		for _, p := range prefixes {
+			if strings.HasPrefix(name, p) && len(name) > len(p) {
+				def.name = name[len(p):]
+				defines[p] = append(defines[p], def)
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Circle defines flags as shifts, tbaMUD defines them as bit numbers.
+	flags := 0
+	wide := true
+	for _, p := range []string{"ROOM_", "MOB_", "AFF_"} {
+		for _, def := range defines[p] {
+			flags++
+			if def.shift {
+				wide = false
+			}
+		}
+	}
+	if flags == 0 {
+		wide = false
+	}
+
+	d := Circle30.copy()
+	if wide {
+		d = TbaMUD.copy()
+	}
+	d.Name = ""
+
+	var err error
+	if defs := defines["ROOM_"]; len(defs) > 0 {
+		if d.RoomFlags, err = sourceFlags(defs, wide); err != nil {
+			return nil, err
+		}
+	}
+	if defs := defines["MOB_"]; len(defs) > 0 {
+		if d.MobFlags, err = sourceFlags(defs, wide); err != nil {
+			return nil, err
+		}
+	}
+	if defs := defines["AFF_"]; len(defs) > 0 {
+		if d.AffectFlags, err = sourceFlags(defs, wide); err != nil {
+			return nil, err
+		}
+	}
+	if defs := defines["SECT_"]; len(defs) > 0 {
+		d.Sectors = sourceTable(defs, func(s string) string { return s })
+	}
+	if defs := defines["POS_"]; len(defs) > 0 {
+		d.Positions = sourceTable(defs, func(s string) string { return "POSITION_" + s })
+	}
+	if defs := defines["SEX_"]; len(defs) > 0 {
+		d.Genders = sourceTable(defs, func(s string) string {
+			return s[:1] + strings.ToLower(s[1:])
+		})
+	}
+	if len(dirs) > 0 {
+		d.Directions = sourceTable(dirs, func(s string) string { return s })
+	}
+	return d, nil
+}
+
+// parseDefine parses a line like "#define ROOM_DARK (1 << 0) /* Dark */".  It
+// returns false for anything that isn't a #define of a number or a shift.
+func parseDefine(s string) (string, sourceDefine, bool) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "#") {
+		return "", sourceDefine{}, false
+	}
+	fields := strings.Fields(s[1:])
+	if len(fields) < 3 || fields[0] != "define" || strings.Contains(fields[1], "(") {
+		return "", sourceDefine{}, false
+	}
+	name := fields[1]
+	expr := strings.Join(fields[2:], " ")
+	if i := strings.Index(expr, "/*"); i >= 0 {
+		expr = expr[:i]
+	}
+	if i := strings.Index(expr, "//"); i >= 0 {
+		expr = expr[:i]
+	}
+	expr = strings.TrimSpace(expr)
+	for strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		expr = strings.TrimSpace(expr[1 : len(expr)-1])
+	}
+	if i := strings.Index(expr, "<<"); i >= 0 {
+		one, ok := parseSourceNumber(expr[:i])
+		if !ok || one != 1 {
+			return "", sourceDefine{}, false
+		}
+		n, ok := parseSourceNumber(expr[i+2:])
+		if !ok {
+			return "", sourceDefine{}, false
+		}
+		return name, sourceDefine{value: n, shift: true}, true
+	}
+	n, ok := parseSourceNumber(expr)
+	if !ok {
+		return "", sourceDefine{}, false
+	}
+	return name, sourceDefine{value: n}, true
+}
+
+// parseSourceNumber parses a C integer literal, such as 5, 0x10 or 1UL.
+func parseSourceNumber(s string) (uint64, bool) {
+	s = strings.TrimRight(strings.TrimSpace(s), "uUlL")
+	n, err := strconv.ParseUint(s, 0, 64)
+	return n, err == nil
+}
+
+// sourceFlags converts flag #defines into a list of flags by bit number.
+// Defines that aren't a single bit (such as masks) are ignored.
+func sourceFlags(defs []sourceDefine, wide bool) ([]string, error) {
+	max := uint64(32)
+	if wide {
+		max = 128
+	}
+	var flags []string
+	for _, def := range defs {
+		n := def.value
+		if !wide && !def.shift {
+			if n == 0 || n&(n-1) != 0 {
+				continue
+			}
+			b := uint64(0)
+			for n > 1 {
+				n >>= 1
+				b++
+			}
+			n = b
+		}
+		if n >= max {
+			return nil, fmt.Errorf("line %d: %s is bit %d, which doesn't fit in the flags", def.line, def.name, n)
+		}
+		for uint64(len(flags)) <= n {
+			flags = append(flags, "")
+		}
+		flags[n] = def.name
+	}
+	return flags, nil
+}
+
+// sourceTable converts number #defines into a table of names by number.
+func sourceTable(defs []sourceDefine, name func(string) string) map[string]string {
+	table := map[string]string{}
+	for _, def := range defs {
+		if def.shift {
+			continue
+		}
+		table[strconv.FormatUint(def.value, 10)] = name(def.name)
+	}
+	return table
+}
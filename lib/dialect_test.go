@@ -0,0 +1,76 @@
+package lib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReadDialectSource(t *testing.T) {
+	d, err := readDialectSource(strings.NewReader(`
+#define ROOM_DARK		(1 << 0)   /* Dark			*/
+#define ROOM_ARENA		(1 << 2)
+#define ROOM_FLAGS(loc)		(world[(loc)].room_flags)
+#define NORTH		0
+#define SECT_DESERT	10	/* custom */
+#define POS_MOUNTED	9
+#define SEX_FEMALE	2
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.WideFlags {
+		t.Error("expected shifted flags not to be wide")
+	}
+	if strings.Join(d.RoomFlags, ",") != "DARK,,ARENA" {
+		t.Errorf("unexpected room flags: %q", d.RoomFlags)
+	}
+	if d.Sectors["10"] != "DESERT" || d.Positions["9"] != "POSITION_MOUNTED" || d.Genders["2"] != "Female" || d.Directions["0"] != "North" {
+		t.Errorf("unexpected tables: %+v", d)
+	}
+	if len(d.MobFlags) != len(Circle30.MobFlags) {
+		t.Errorf("expected mob flags to come from circle30, got %q", d.MobFlags)
+	}
+
+	d, err = readDialectSource(strings.NewReader(`
+#define ROOM_DARK	0
+#define ROOM_WORLDMAP	16
+#define AFF_DONTUSE	0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !d.WideFlags {
+		t.Error("expected flags defined by bit number to be wide")
+	}
+	if len(d.RoomFlags) != 17 || d.RoomFlags[16] != "WORLDMAP" {
+		t.Errorf("unexpected room flags: %q", d.RoomFlags)
+	}
+}
+
+func TestOpposites(t *testing.T) {
+	opposites := map[string]string{
+		"0": "2", "1": "3", "2": "0", "3": "1", "4": "5", "5": "4",
+		"6": "8", "7": "9", "8": "6", "9": "7", "10": "11", "11": "10",
+	}
+	for _, d := range []*Dialect{Circle30, Circle31, TbaMUD} {
+		for dir, opp := range opposites {
+			if got := d.Opposites[d.Directions[dir]]; got != d.Directions[opp] {
+				t.Errorf("%s: expected the opposite of D%s to be %s, got %q", d.Name, dir, d.Directions[opp], got)
+			}
+		}
+	}
+
+	name := filepath.Join(t.TempDir(), "mymud.json")
+	if err := ioutil.WriteFile(name, []byte(`{"directions": {"12": "Portal"}, "opposites": {"Portal": "Portal"}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	d, err := LoadDialect(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Opposites["Northwest"] != "Southeast" || d.Opposites["Portal"] != "Portal" {
+		t.Errorf("unexpected opposites: %v", d.Opposites)
+	}
+}
@@ -0,0 +1,197 @@
+package lib
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf8"
+)
+
+// Encoding is a character encoding that MUD files may be written in.  The
+// single byte encodings are all ASCII for bytes below 0x80.
+type Encoding struct {
+	// Name is the name of the encoding, as given to -encoding.
+	Name string
+	// high holds the characters for the bytes 0x80 to 0xFF, or is nil for
+	// UTF-8.
+	high []rune
+	// bytes is the reverse of high.
+	bytes map[rune]byte
+}
+
+// The encodings that files can be converted from and to.
+var (
+	// UTF8 leaves the file as it is.
+	UTF8 = &Encoding{Name: "utf8"}
+	// Latin1 is ISO 8859-1, used by most old MUDs on Unix.
+	Latin1 = newEncoding("latin1", latin1High())
+	// CP1252 is Windows' version of Latin-1, which has punctuation such as
+	// curly quotes in place of the control characters 0x80 to 0x9F.
+	CP1252 = newEncoding("cp1252", cp1252High())
+	// CP437 is the original IBM PC character set, with the box drawing
+	// characters used in maps.
+	CP437 = newEncoding("cp437", []rune(cp437High))
+)
+
+// Encodings holds the encodings by name.
+var Encodings = map[string]*Encoding{
+	UTF8.Name:   UTF8,
+	Latin1.Name: Latin1,
+	CP1252.Name: CP1252,
+	CP437.Name:  CP437,
+}
+
+func newEncoding(name string, high []rune) *Encoding {
+	if len(high) != 128 {
+		panic(fmt.Sprintf("%s has %d characters for 0x80-0xFF", name, len(high)))
+	}
+	e := &Encoding{Name: name, high: high, bytes: map[rune]byte{}}
+	for i, r := range high {
+		e.bytes[r] = byte(0x80 + i)
+	}
+	return e
+}
+
+func latin1High() []rune {
+	high := make([]rune, 128)
+	for i := range high {
+		high[i] = rune(0x80 + i)
+	}
+	return high
+}
+
+func cp1252High() []rune {
+	high := latin1High()
+	// 0x81, 0x8D, 0x8F, 0x90 and 0x9D aren't used, and are left as the Latin-1
+	// control characters, so that they still survive a round trip.
+	for i, r := range []rune("€\u0081‚ƒ„…†‡ˆ‰Š‹Œ\u008DŽ\u008F\u0090‘’“”•–—˜™š›œ\u009DžŸ") {
+		high[i] = r
+	}
+	return high
+}
+
+const cp437High = "ÇüéâäàåçêëèïîìÄÅ" +
+	"ÉæÆôöòûùÿÖÜ¢£¥₧ƒ" +
+	"áíóúñÑªº¿⌐¬½¼¡«»" +
+	"░▒▓│┤╡╢╖╕╣║╗╝╜╛┐" +
+	"└┴┬├─┼╞╟╚╔╩╦╠═╬╧" +
+	"╨╤╥╙╘╒╓╫╪┘┌█▄▌▐▀" +
+	"αßΓπΣσµτΦΘΩδ∞φε∩" +
+	"≡±≥≤⌠⌡÷≈°∙·√ⁿ²■ "
+
+// Decode converts text in the encoding to UTF-8.
+func (e *Encoding) Decode(b []byte) string {
+	if e.high == nil {
+		return string(b)
+	}
+	return string(e.appendDecoded(make([]byte, 0, len(b)), b))
+}
+
+// appendDecoded appends b, converted to UTF-8, to dst.
+func (e *Encoding) appendDecoded(dst, b []byte) []byte {
+	var buf [utf8.UTFMax]byte
+	for _, c := range b {
+		if c < 0x80 {
+			dst = append(dst, c)
+			continue
+		}
+		n := utf8.EncodeRune(buf[:], e.high[c-0x80])
+		dst = append(dst, buf[:n]...)
+	}
+	return dst
+}
+
+// NewDecoder returns a reader that converts what's read from r from the
+// encoding to UTF-8.
+func (e *Encoding) NewDecoder(r io.Reader) io.Reader {
+	if e.high == nil {
+		return r
+	}
+	return &decoder{r: r, e: e, in: make([]byte, 4096)}
+}
+
+type decoder struct {
+	r   io.Reader
+	e   *Encoding
+	in  []byte
+	buf []byte
+	// out is what's been decoded but not read yet.
+	out []byte
+	err error
+}
+
+func (d *decoder) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		n, err := d.r.Read(d.in)
+		d.buf = d.e.appendDecoded(d.buf[:0], d.in[:n])
+		d.out = d.buf
+		d.err = err
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+// Encode converts UTF-8 text to the encoding.  It returns an error if the text
+// has a character that the encoding doesn't have.
+func (e *Encoding) Encode(s string) ([]byte, error) {
+	if e.high == nil {
+		return []byte(s), nil
+	}
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r < 0x80 {
+			b = append(b, byte(r))
+			continue
+		}
+		c, ok := e.bytes[r]
+		if !ok {
+			return nil, fmt.Errorf("%q can't be written in %s", r, e.Name)
+		}
+		b = append(b, c)
+	}
+	return b, nil
+}
+
+// DetectEncoding guesses the encoding of a file from its contents.  Files that
+// are valid UTF-8 (which includes plain ASCII) are UTF-8.  Otherwise it's a
+// guess between CP437, whose box drawing characters are 0xB0 to 0xDF, and
+// CP1252, whose lower case accented letters are 0xE0 to 0xFF.  b may be just
+// the start of a file, so a character cut off at the end is ignored.
+func DetectEncoding(b []byte) *Encoding {
+	for i := 1; i <= utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				b = b[:len(b)-i]
+			}
+			break
+		}
+	}
+	if utf8.Valid(b) {
+		return UTF8
+	}
+	box, accented := 0, 0
+	for _, c := range b {
+		switch {
+		case c >= 0xB0 && c <= 0xDF:
+			box++
+		case c >= 0xE0:
+			accented++
+		}
+	}
+	if box > accented {
+		return CP437
+	}
+	return CP1252
+}
+
+// encodingName returns the encoding to write to the json for a file.  UTF-8
+// files leave it out.
+func encodingName(e *Encoding) string {
+	if e == nil || e == UTF8 {
+		return ""
+	}
+	return e.Name
+}
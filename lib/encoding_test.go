@@ -0,0 +1,95 @@
+package lib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncodings(t *testing.T) {
+	tests := []struct {
+		enc  *Encoding
+		in   []byte
+		text string
+	}{
+		{CP437, []byte("\xc9\xcd\xbb \x80a"), "╔═╗ Ça"},
+		{Latin1, []byte("Caf\xe9 \x93"), "Café \u0093"},
+		{CP1252, []byte("Caf\xe9 \x93hi\x94"), "Café “hi”"},
+		{UTF8, []byte("Café"), "Café"},
+	}
+	for _, test := range tests {
+		s := test.enc.Decode(test.in)
+		if s != test.text {
+			t.Errorf("%s: expected %q, got %q", test.enc.Name, test.text, s)
+		}
+		b, err := test.enc.Encode(s)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.enc.Name, err)
+		}
+		if !bytes.Equal(b, test.in) {
+			t.Errorf("%s: expected %q, got %q", test.enc.Name, test.in, b)
+		}
+	}
+	if _, err := CP437.Encode("€"); err == nil {
+		t.Error("expected an error for a character cp437 doesn't have")
+	}
+}
+
+func TestDetectEncoding(t *testing.T) {
+	tests := []struct {
+		in  string
+		enc *Encoding
+	}{
+		{"plain", UTF8},
+		{"Café", UTF8},
+		{"\xc9\xcd\xcd\xbb\n\xc8\xcd\xcd\xbc", CP437},
+		{"Na\xefve r\xe9sum\xe9", CP1252},
+	}
+	for _, test := range tests {
+		if enc := DetectEncoding([]byte(test.in)); enc != test.enc {
+			t.Errorf("%q: expected %s, got %s", test.in, test.enc.Name, enc.Name)
+		}
+	}
+}
+
+func TestDetectEncodingAfterStart(t *testing.T) {
+	var b strings.Builder
+	for i := 1; b.Len() <= detectSize; i++ {
+		fmt.Fprintf(&b, "#%d\nCafé~\nA café.\n~\n0 0 0\nS\n", i)
+	}
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.wld")
+	if err := ioutil.WriteFile(good, []byte(b.String()+"$\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConvertRoomFiles(filepath.Join(dir, "good"), []string{good}, Options{}); err != nil {
+		t.Fatalf("unexpected error for a UTF-8 file: %v", err)
+	}
+
+	bad := filepath.Join(dir, "bad.wld")
+	b.WriteString("#5000\nCaf\xe9~\nA caf\xe9.\n~\n0 0 0\nS\n$\n")
+	if err := ioutil.WriteFile(bad, []byte(b.String()), 0600); err != nil {
+		t.Fatal(err)
+	}
+	to := filepath.Join(dir, "bad")
+	err := ConvertRoomFiles(to, []string{bad}, Options{})
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected a *ParseError, got %#v", err)
+	}
+	if !strings.Contains(err.Error(), "-encoding") {
+		t.Errorf("expected the error to say to pass -encoding, got %q", err)
+	}
+	if _, err := os.Stat(filepath.Join(to, "bad.json")); !os.IsNotExist(err) {
+		t.Errorf("expected no output for a file that failed to decode, got %v", err)
+	}
+
+	if err := ConvertRoomFiles(to, []string{bad}, Options{Encoding: Latin1}); err != nil {
+		t.Fatalf("unexpected error with the encoding given: %v", err)
+	}
+}
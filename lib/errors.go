@@ -0,0 +1,127 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ParseError is the error returned when a file can't be parsed.  Use
+// errors.As to get at it:
+//
+//	var perr *lib.ParseError
+//	if errors.As(err, &perr) {
+//		fmt.Println(perr.File, perr.Line)
+//	}
+type ParseError struct {
+	File string
+	Line int
+	// Record is the number (vnum) of the room, mob, zone etc. being parsed,
+	// or -1 if the error wasn't in a record, such as a bad #<vnum> line.
+	Record int
+	// Expected describes what the parser expected to find, such as
+	// "room name to end with ~".  It's empty if the error isn't about the
+	// layout of the file (such as an unknown sector type).
+	Expected string
+	// Text is the text that couldn't be parsed.
+	Text string
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%v - %s", e.File, e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// syntaxError is the error for text that doesn't match what the parser
+// expected.
+type syntaxError struct {
+	expected string
+	text     string
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("expected %s, but got %q", e.expected, e.text)
+}
+
+// expectedError returns an error saying that what was expected wasn't found,
+// and text was found instead.
+func expectedError(what, text string) error {
+	return &syntaxError{expected: what, text: text}
+}
+
+// parseError wraps an error from scanning the named file with where in the
+// file it happened.
+func (f *fileScanner) parseError(name string, err error) error {
+	perr := &ParseError{
+		File:   name,
+		Line:   *f.line,
+		Record: -1,
+		Text:   f.Text(),
+		Err:    err,
+	}
+	if f.hasRecord {
+		perr.Record = f.record
+	}
+	var serr *syntaxError
+	if errors.As(err, &serr) {
+		perr.Expected = serr.expected
+		perr.Text = serr.text
+	}
+	return perr
+}
+
+// FileErrors are all the errors found in one file.
+type FileErrors struct {
+	File string
+	Errs []error
+}
+
+// ErrorList is the error returned by the ConvertXFiles functions in keep going
+// mode, with the errors from every file that had any, in the order the files
+// were converted.
+type ErrorList []FileErrors
+
+func (l ErrorList) Error() string {
+	count := 0
+	for _, f := range l {
+		count += len(f.Errs)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d errors in %d files", count, len(l))
+	for _, f := range l {
+		fmt.Fprintf(&b, "\n%s:", f.File)
+		for _, err := range f.Errs {
+			fmt.Fprintf(&b, "\n    %s", err)
+		}
+	}
+	return b.String()
+}
+
+// errorCollector collects the errors from each file in keep going mode.
+type errorCollector struct {
+	keepGoing bool
+	list      ErrorList
+}
+
+// add records the errors from the named file.  If not in keep going mode, it
+// returns the first one, to stop at.
+func (c *errorCollector) add(file string, errs ...error) error {
+	if !c.keepGoing {
+		return errs[0]
+	}
+	c.list = append(c.list, FileErrors{File: file, Errs: errs})
+	return nil
+}
+
+// err returns the errors that were collected, if there were any.
+func (c *errorCollector) err() error {
+	if len(c.list) == 0 {
+		return nil
+	}
+	return c.list
+}
@@ -2,23 +2,107 @@ package lib
 
 import (
 	"bufio"
+	"bytes"
 	"errors"
+	"strconv"
 	"strings"
 )
 
 type fileScanner struct {
 	line *int
 	*bufio.Scanner
+	// dialect is the dialect of the file being scanned, or nil to choose one
+	// based on the format of each record.
+	dialect *Dialect
+	// detected is the dialect chosen from the format of the records, if
+	// dialect is nil.  If any record was tbaMUD, it's tbaMUD.
+	detected *Dialect
+	// record is the number of the record being scanned, if hasRecord is set.
+	record    int
+	hasRecord bool
+	// text is the current line, without its line ending.
+	text string
+	// started is set once the first line has been scanned.
+	started bool
+	// crlf and lf count the lines that ended in \r\n and in a bare \n.
+	crlf, lf int
+}
+
+// forFormat returns the dialect to use for a record, and notes which one was
+// chosen if it was chosen from the format of the record.
+func (f *fileScanner) forFormat(wide bool) *Dialect {
+	d := f.dialect.forFormat(wide)
+	if f.dialect == nil && f.detected != TbaMUD {
+		f.detected = d
+	}
+	return d
+}
+
+// startRecord notes the number of the record being scanned, for errors.
+func (f *fileScanner) startRecord(num int) {
+	f.record = num
+	f.hasRecord = true
+}
+
+// endRecord notes that no record is being scanned, so that errors between
+// records don't name the last one.
+func (f *fileScanner) endRecord() {
+	f.hasRecord = false
 }
 
 func (f *fileScanner) Scan() bool {
+	if !f.started {
+		// split the lines ourselves, so we can see which line ending was used.
+		f.Scanner.Split(scanLines)
+		f.started = true
+	}
 	b := f.Scanner.Scan()
+	f.text = ""
 	if b {
 		(*(f.line))++
+		f.text = f.Scanner.Text()
+		if strings.HasSuffix(f.text, "\r") {
+			// files that went through FTP can end up with \r\r\n.
+			f.text = strings.TrimRight(f.text, "\r")
+			f.crlf++
+		} else {
+			f.lf++
+		}
+		// whitespace after a ~ at the end of a line is easy to add by accident
+		// and invisible, so ignore it.
+		if t := strings.TrimRight(f.text, " \t"); strings.HasSuffix(t, "~") {
+			f.text = t
+		}
 	}
 	return b
 }
 
+// Text returns the current line, without its line ending.
+func (f *fileScanner) Text() string {
+	return f.text
+}
+
+// usesCRLF reports whether most of the lines scanned so far ended in \r\n.
+func (f *fileScanner) usesCRLF() bool {
+	return f.crlf > f.lf
+}
+
+// scanLines is like bufio.ScanLines, except that it leaves the \r of a \r\n
+// on the line, so that fileScanner can tell which line ending was used.
+func scanLines(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	// request more data.
+	return 0, nil, nil
+}
+
 func (f *fileScanner) MustScan() error {
 	if !f.Scan() {
 		if err := f.Err(); err != nil {
@@ -59,3 +143,29 @@ func (f *fileScanner) ScanUntilPrefix(prefixes []string) (string, error) {
 		lines = append(lines, s)
 	}
 }
+
+// skipToRecord skips past the rest of a record that couldn't be parsed, which
+// started on line start, to the start of the next record (#<vnum>) or the end
+// of the file ($).  It returns false if there's nothing left in the file.
+func (f *fileScanner) skipToRecord(start int) bool {
+	f.endRecord()
+	for {
+		s := strings.TrimSpace(f.Text())
+		if *f.line != start && (s == "$" || isRecordStart(s)) {
+			return true
+		}
+		if !f.Scan() {
+			return false
+		}
+	}
+}
+
+// isRecordStart reports whether the line is the #<vnum> line that starts a
+// record.
+func isRecordStart(s string) bool {
+	if !strings.HasPrefix(s, "#") {
+		return false
+	}
+	_, err := strconv.Atoi(s[1:])
+	return err == nil
+}
@@ -0,0 +1,66 @@
+package lib
+
+import (
+	"bufio"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseHelpEntry(t *testing.T) {
+	r := strings.NewReader(
+		`NORTH SOUTH "GO NORTH"
+
+Usage: north
+#31
+$
+`)
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	if err := scanner.MustScan(); err != nil {
+		t.Fatal(err)
+	}
+	h, err := scanHelpEntry(scanner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"NORTH", "SOUTH", "GO NORTH"}
+	if !reflect.DeepEqual(h.Keywords, expected) {
+		t.Errorf("expected keywords %q, got %q", expected, h.Keywords)
+	}
+	if h.Level != 31 {
+		t.Errorf("expected level 31, got %d", h.Level)
+	}
+	if h.Body != "\nUsage: north" {
+		t.Errorf("unexpected body: %q", h.Body)
+	}
+}
+
+func TestParseHelpEndMarker(t *testing.T) {
+	entries, err := ParseHelp(strings.NewReader("FOO~\nfoo help\n#\n$~\n"), "foo.hlp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Body != "foo help" {
+		t.Errorf("expected a single entry, got %+v", entries)
+	}
+}
+
+func TestDuplicateHelpKeywords(t *testing.T) {
+	entries := []*HelpEntry{
+		{File: "a.hlp", Line: 1, Keywords: []string{"NORTH", "N"}},
+		{File: "a.hlp", Line: 5, Keywords: []string{"SOUTH"}},
+		{File: "b.hlp", Line: 1, Keywords: []string{"north"}},
+	}
+	dupes := DuplicateHelpKeywords(entries)
+	expected := map[string][]HelpLocation{
+		"NORTH": {{File: "a.hlp", Line: 1}, {File: "b.hlp", Line: 1}},
+	}
+	if !reflect.DeepEqual(dupes, expected) {
+		t.Errorf("expected duplicates %v, got %v", expected, dupes)
+	}
+}
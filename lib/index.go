@@ -0,0 +1,98 @@
+package lib
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Index is the list of files a CircleMUD world index file (such as index or
+// index.mini) tells the server to boot.
+type Index struct {
+	// Files are the files listed in the index that exist, in index order.
+	Files []string
+	// Missing are the files listed in the index that don't exist.
+	Missing []string
+	// Unlisted are the files matching the pattern that are not listed in any
+	// index file in the directory.
+	Unlisted []string
+}
+
+// ReadIndex reads the named index file in dir.  Files in dir that match the
+// pattern are checked against every index file in dir (index, index.mini,
+// etc), so that stray files that would never be booted can be reported.
+func ReadIndex(dir, name, pattern string) (*Index, error) {
+	entries, err := readIndexFile(filepath.Join(dir, name))
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{}
+	for _, e := range entries {
+		file := filepath.Join(dir, e)
+		if _, err := os.Stat(file); err != nil {
+			if os.IsNotExist(err) {
+				idx.Missing = append(idx.Missing, file)
+				continue
+			}
+			return nil, err
+		}
+		idx.Files = append(idx.Files, file)
+	}
+
+	listed := map[string]bool{}
+	indexes, err := filepath.Glob(filepath.Join(dir, "index*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, index := range indexes {
+		base := filepath.Base(index)
+		if base != "index" && !strings.HasPrefix(base, "index.") {
+			continue
+		}
+		entries, err := readIndexFile(index)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			listed[filepath.Join(dir, e)] = true
+		}
+	}
+	files, err := filepath.Glob(filepath.Join(dir, pattern))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if !listed[file] {
+			idx.Unlisted = append(idx.Unlisted, file)
+		}
+	}
+	return idx, nil
+}
+
+// readIndexFile returns the entries in an index file, up to the terminating $.
+func readIndexFile(filename string) ([]string, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		s := strings.TrimSpace(scanner.Text())
+		if s == "$" {
+			return entries, nil
+		}
+		if s == "" {
+			continue
+		}
+		entries = append(entries, s)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s - %s", filename, err)
+	}
+	return entries, nil
+}
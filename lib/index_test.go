@@ -0,0 +1,49 @@
+package lib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestReadIndex(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"index":      "31.wld\n30.wld\n99.wld\n$\n",
+		"index.mini": "30.wld\n40.wld\n$\n",
+		"30.wld":     "$\n",
+		"31.wld":     "$\n",
+		"40.wld":     "$\n",
+		"30.wld.bak": "$\n",
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	idx, err := ReadIndex(dir, "index", "*.wld*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := func(names ...string) []string {
+		for i, n := range names {
+			names[i] = filepath.Join(dir, n)
+		}
+		return names
+	}
+	if want := path("31.wld", "30.wld"); !reflect.DeepEqual(idx.Files, want) {
+		t.Errorf("expected files %q in index order, got %q", want, idx.Files)
+	}
+	if want := path("99.wld"); !reflect.DeepEqual(idx.Missing, want) {
+		t.Errorf("expected missing %q, got %q", want, idx.Missing)
+	}
+	// 40.wld is only in index.mini, which still counts as listed.
+	if want := path("30.wld.bak"); !reflect.DeepEqual(idx.Unlisted, want) {
+		t.Errorf("expected unlisted %q, got %q", want, idx.Unlisted)
+	}
+
+	if _, err := ReadIndex(dir, "index.missing", "*.wld"); err == nil {
+		t.Error("expected an error for an index file that doesn't exist")
+	}
+}
@@ -0,0 +1,21 @@
+package lib
+
+import "bytes"
+
+// CRLF is the line_ending written to the json for files that used Windows
+// (\r\n) line endings, so that they can be written back the same way.  Files
+// with Unix (\n) line endings leave line_ending out.
+const CRLF = "crlf"
+
+// lineEnding returns the line_ending to write to the json for a file.
+func lineEnding(crlf bool) string {
+	if crlf {
+		return CRLF
+	}
+	return ""
+}
+
+// toCRLF converts the \n line endings written by the Write functions to \r\n.
+func toCRLF(b []byte) []byte {
+	return bytes.Replace(b, []byte("\n"), []byte("\r\n"), -1)
+}
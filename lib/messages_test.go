@@ -0,0 +1,106 @@
+package lib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseMessages(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "messages")
+	err := ioutil.WriteFile(name, []byte(`* Slash
+M
+ 303
+Your slash kills $N!
+$n's slash kills you!
+$n's slash kills $N!
+You miss $N with your slash.
+$n misses you with $s slash.
+$n misses $N with $s slash.
+You slash $N.
+$n slashes you.
+$n slashes $N.
+#
+#
+$n tries to slash $N, but can't.
+
+* Magic missile
+M
+ 32
+Your magic missile kills $N.
+$n's magic missile kills you.
+$n kills $N with a magic missile.
+#
+#
+#
+You hit $N with a magic missile.
+$n hits you with a magic missile.
+$n hits $N with a magic missile.
+#
+#
+#
+
+M
+ 303
+$N is cut in two!
+$n cuts you in two!
+$n cuts $N in two!
+You miss.
+$n misses you.
+$n misses $N.
+You cut $N.
+$n cuts you.
+$n cuts $N.
+You can't hurt $N.
+$n can't hurt you.
+$n can't hurt $N.
+$
+`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := ParseMessagesFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("expected 2 attack types, got %d", len(msgs))
+	}
+	slash, missile := msgs[0], msgs[1]
+	if slash.AttackType != 303 || slash.Name != "slash" || len(slash.Messages) != 2 {
+		t.Errorf("expected both slash message sets grouped under 303, got %+v", slash)
+	}
+	if missile.AttackType != 32 || missile.Name != "" || len(missile.Messages) != 1 {
+		t.Errorf("expected one unnamed message set for spell 32, got %+v", missile)
+	}
+
+	set := slash.Messages[0]
+	for _, test := range []struct {
+		msg  *string
+		want string
+	}{
+		{set.Die.ToAttacker, "Your slash kills $N!"},
+		{set.Die.ToVictim, "$n's slash kills you!"},
+		{set.Die.ToRoom, "$n's slash kills $N!"},
+		{set.Miss.ToAttacker, "You miss $N with your slash."},
+		{set.Miss.ToVictim, "$n misses you with $s slash."},
+		{set.Miss.ToRoom, "$n misses $N with $s slash."},
+		{set.Hit.ToAttacker, "You slash $N."},
+		{set.Hit.ToVictim, "$n slashes you."},
+		{set.Hit.ToRoom, "$n slashes $N."},
+		{set.God.ToRoom, "$n tries to slash $N, but can't."},
+	} {
+		if test.msg == nil || *test.msg != test.want {
+			t.Errorf("expected %q, got %v", test.want, test.msg)
+		}
+	}
+	if set.God.ToAttacker != nil || set.God.ToVictim != nil {
+		t.Errorf("expected # messages to be null, got %v and %v", set.God.ToAttacker, set.God.ToVictim)
+	}
+	if m := missile.Messages[0].Miss; m.ToAttacker != nil || m.ToVictim != nil || m.ToRoom != nil {
+		t.Errorf("expected no miss messages, got %+v", m)
+	}
+	if s := slash.Messages[1].God.ToRoom; s == nil || *s != "$n can't hurt $N." {
+		t.Errorf("unexpected god message in the second slash set: %v", s)
+	}
+}
@@ -65,7 +65,7 @@ var MobActionBits = map[int]string{
 
 // MobActionsToNames converts a mob's action bitvector into a list of bit names
 func MobActionsToNames(vector string) ([]string, error) {
-	return BitsToNames(vector, MOB_ACTION_NOBLIND, MobActionBits, MobActionChars)
+	return AllBitsToNames(vector, MobActionBits, MobActionChars)
 }
 
 const (
@@ -145,5 +145,5 @@ var MobAffectionChars = map[rune]string{
 
 // MobAffectionsToNames converts a mob's affection bitvector into a list of bit names
 func MobAffectionsToNames(vector string) ([]string, error) {
-	return BitsToNames(vector, MOB_AFFECTION_CHARM, MobAffectionBits, MobAffectionChars)
+	return AllBitsToNames(vector, MobAffectionBits, MobAffectionChars)
 }
@@ -0,0 +1,60 @@
+package lib
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestParseEMob(t *testing.T) {
+	r := strings.NewReader(
+		`#3000
+wizard~
+the wizard~
+A wizard walks around behind the counter, talking to himself.
+~
+The wizard looks old and senile.
+~
+abd 0 900 E
+33 2 2 1d1+1000 1d8+10
+100 3000
+8 8 1
+BareHandAttack: 12
+Str: 18
+StrAdd: 50
+Luck: 3
+E
+$
+`)
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	if err := scanner.MustScan(); err != nil {
+		t.Fatal(err)
+	}
+	m, err := scanMob(scanner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.BareHandAttack != "blast" {
+		t.Errorf("expected bare hand attack blast, got %q", m.BareHandAttack)
+	}
+	if m.Str == nil || *m.Str != 18 {
+		t.Errorf("expected Str 18, got %v", m.Str)
+	}
+	if m.StrAdd == nil || *m.StrAdd != 50 {
+		t.Errorf("expected StrAdd 50, got %v", m.StrAdd)
+	}
+	if m.Int != nil {
+		t.Errorf("expected Int to be unset, got %v", *m.Int)
+	}
+	if m.Extra["Luck"] != "3" {
+		t.Errorf("expected unknown key Luck to be kept, got %v", m.Extra)
+	}
+	if strings.TrimSpace(scanner.Text()) != "$" {
+		t.Errorf("expected scanner to stop at end of file, but it's at %q", scanner.Text())
+	}
+}
@@ -0,0 +1,125 @@
+package lib
+
+const (
+	OBJ_EXTRA_GLOW int = 1 << iota
+	OBJ_EXTRA_HUM
+	OBJ_EXTRA_NORENT
+	OBJ_EXTRA_NODONATE
+	OBJ_EXTRA_NOINVIS
+	OBJ_EXTRA_INVISIBLE
+	OBJ_EXTRA_MAGIC
+	OBJ_EXTRA_NODROP
+	OBJ_EXTRA_BLESS
+	OBJ_EXTRA_ANTI_GOOD
+	OBJ_EXTRA_ANTI_EVIL
+	OBJ_EXTRA_ANTI_NEUTRAL
+	OBJ_EXTRA_ANTI_MAGIC_USER
+	OBJ_EXTRA_ANTI_CLERIC
+	OBJ_EXTRA_ANTI_THIEF
+	OBJ_EXTRA_ANTI_WARRIOR
+	OBJ_EXTRA_NOSELL
+)
+
+var ObjExtraChars = map[rune]string{
+	'a': "GLOW",
+	'b': "HUM",
+	'c': "NORENT",
+	'd': "NODONATE",
+	'e': "NOINVIS",
+	'f': "INVISIBLE",
+	'g': "MAGIC",
+	'h': "NODROP",
+	'i': "BLESS",
+	'j': "ANTI_GOOD",
+	'k': "ANTI_EVIL",
+	'l': "ANTI_NEUTRAL",
+	'm': "ANTI_MAGIC_USER",
+	'n': "ANTI_CLERIC",
+	'o': "ANTI_THIEF",
+	'p': "ANTI_WARRIOR",
+	'q': "NOSELL",
+}
+
+var ObjExtraBits = map[int]string{
+	OBJ_EXTRA_GLOW:            "GLOW",
+	OBJ_EXTRA_HUM:             "HUM",
+	OBJ_EXTRA_NORENT:          "NORENT",
+	OBJ_EXTRA_NODONATE:        "NODONATE",
+	OBJ_EXTRA_NOINVIS:         "NOINVIS",
+	OBJ_EXTRA_INVISIBLE:       "INVISIBLE",
+	OBJ_EXTRA_MAGIC:           "MAGIC",
+	OBJ_EXTRA_NODROP:          "NODROP",
+	OBJ_EXTRA_BLESS:           "BLESS",
+	OBJ_EXTRA_ANTI_GOOD:       "ANTI_GOOD",
+	OBJ_EXTRA_ANTI_EVIL:       "ANTI_EVIL",
+	OBJ_EXTRA_ANTI_NEUTRAL:    "ANTI_NEUTRAL",
+	OBJ_EXTRA_ANTI_MAGIC_USER: "ANTI_MAGIC_USER",
+	OBJ_EXTRA_ANTI_CLERIC:     "ANTI_CLERIC",
+	OBJ_EXTRA_ANTI_THIEF:      "ANTI_THIEF",
+	OBJ_EXTRA_ANTI_WARRIOR:    "ANTI_WARRIOR",
+	OBJ_EXTRA_NOSELL:          "NOSELL",
+}
+
+// ObjExtrasToNames converts an object's extra (effects) bitvector into a list of bit names
+func ObjExtrasToNames(vector string) ([]string, error) {
+	return AllBitsToNames(vector, ObjExtraBits, ObjExtraChars)
+}
+
+const (
+	OBJ_WEAR_TAKE int = 1 << iota
+	OBJ_WEAR_FINGER
+	OBJ_WEAR_NECK
+	OBJ_WEAR_BODY
+	OBJ_WEAR_HEAD
+	OBJ_WEAR_LEGS
+	OBJ_WEAR_FEET
+	OBJ_WEAR_HANDS
+	OBJ_WEAR_ARMS
+	OBJ_WEAR_SHIELD
+	OBJ_WEAR_ABOUT
+	OBJ_WEAR_WAIST
+	OBJ_WEAR_WRIST
+	OBJ_WEAR_WIELD
+	OBJ_WEAR_HOLD
+)
+
+var ObjWearChars = map[rune]string{
+	'a': "TAKE",
+	'b': "FINGER",
+	'c': "NECK",
+	'd': "BODY",
+	'e': "HEAD",
+	'f': "LEGS",
+	'g': "FEET",
+	'h': "HANDS",
+	'i': "ARMS",
+	'j': "SHIELD",
+	'k': "ABOUT",
+	'l': "WAIST",
+	'm': "WRIST",
+	'n': "WIELD",
+	'o': "HOLD",
+}
+
+var ObjWearBits = map[int]string{
+	OBJ_WEAR_TAKE:   "TAKE",
+	OBJ_WEAR_FINGER: "FINGER",
+	OBJ_WEAR_NECK:   "NECK",
+	OBJ_WEAR_BODY:   "BODY",
+	OBJ_WEAR_HEAD:   "HEAD",
+	OBJ_WEAR_LEGS:   "LEGS",
+	OBJ_WEAR_FEET:   "FEET",
+	OBJ_WEAR_HANDS:  "HANDS",
+	OBJ_WEAR_ARMS:   "ARMS",
+	OBJ_WEAR_SHIELD: "SHIELD",
+	OBJ_WEAR_ABOUT:  "ABOUT",
+	OBJ_WEAR_WAIST:  "WAIST",
+	OBJ_WEAR_WRIST:  "WRIST",
+	OBJ_WEAR_WIELD:  "WIELD",
+	OBJ_WEAR_HOLD:   "HOLD",
+}
+
+// ObjWearToNames converts an object's wear bitvector into a list of bit names
+func ObjWearToNames(vector string) ([]string, error) {
+	return AllBitsToNames(vector, ObjWearBits, ObjWearChars)
+}
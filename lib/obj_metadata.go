@@ -0,0 +1,58 @@
+package lib
+
+// ObjTypes is the conversion between CircleMUD's item type number and a human-readable string.
+var ObjTypes = map[string]string{
+	"1":  "LIGHT",      // Item is a light source.
+	"2":  "SCROLL",     // Item is a magical scroll.
+	"3":  "WAND",       // Item is a magical wand.
+	"4":  "STAFF",      // Item is a magical staff.
+	"5":  "WEAPON",     // Item is a weapon.
+	"6":  "FIREWEAPON", // Currently not implemented.  Do not use.
+	"7":  "MISSILE",    // Currently not implemented.  Do not use.
+	"8":  "TREASURE",   // Item is a treasure, not gold.
+	"9":  "ARMOR",      // Item is armor.
+	"10": "POTION",     // Item is a magical potion.
+	"11": "WORN",       // Currently not implemented.  Do not use.
+	"12": "OTHER",      // Misc object.
+	"13": "TRASH",      // Trash - junked by cleaners, not bought by shopkeepers.
+	"14": "TRAP",       // Currently not implemented.  Do not use.
+	"15": "CONTAINER",  // Item is a container.
+	"16": "NOTE",       // Item is note (can be written on).
+	"17": "DRINKCON",   // Item is a drink container.
+	"18": "KEY",        // Item is a key.
+	"19": "FOOD",       // Item is food.
+	"20": "MONEY",      // Item is money (gold).
+	"21": "PEN",        // Item is a pen.
+	"22": "BOAT",       // Item is a boat; allows you to traverse WATER_NOSWIM.
+	"23": "FOUNTAIN",   // Item is a fountain.
+}
+
+// ApplyLocations is the conversion between CircleMUD's affect (apply) location
+// number and a human-readable string.
+var ApplyLocations = map[string]string{
+	"0":  "NONE",
+	"1":  "STR",
+	"2":  "DEX",
+	"3":  "INT",
+	"4":  "WIS",
+	"5":  "CON",
+	"6":  "CHA",
+	"7":  "CLASS",
+	"8":  "LEVEL",
+	"9":  "AGE",
+	"10": "CHAR_WEIGHT",
+	"11": "CHAR_HEIGHT",
+	"12": "MANA",
+	"13": "HIT",
+	"14": "MOVE",
+	"15": "GOLD",
+	"16": "EXP",
+	"17": "AC",
+	"18": "HITROLL",
+	"19": "DAMROLL",
+	"20": "SAVING_PARA",
+	"21": "SAVING_ROD",
+	"22": "SAVING_PETRI",
+	"23": "SAVING_BREATH",
+	"24": "SAVING_SPELL",
+}
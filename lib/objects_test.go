@@ -0,0 +1,55 @@
+package lib
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestParseObject(t *testing.T) {
+	r := strings.NewReader(
+		`#3000
+barrel beer~
+a barrel~
+A beer barrel has been left here.~
+~
+15 0 a
+50 0 0 0
+30 60 10
+E
+barrel~
+It is made of wood.
+~
+A
+18 1
+$
+`)
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	if err := scanner.MustScan(); err != nil {
+		t.Fatal(err)
+	}
+	obj, err := scanObj(scanner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if obj.Type != "CONTAINER" {
+		t.Errorf("expected type CONTAINER, got %q", obj.Type)
+	}
+	if len(obj.Wear) != 1 || obj.Wear[0] != "TAKE" {
+		t.Errorf("expected wear bits [TAKE], got %v", obj.Wear)
+	}
+	if len(obj.ExtraDescs) != 1 {
+		t.Errorf("expected 1 extra description, got %d", len(obj.ExtraDescs))
+	}
+	if len(obj.Affects) != 1 || obj.Affects[0].Location != "HITROLL" || obj.Affects[0].Value != 1 {
+		t.Errorf("expected affect HITROLL 1, got %v", obj.Affects)
+	}
+	if strings.TrimSpace(scanner.Text()) != "$" {
+		t.Errorf("expected scanner to stop at end of file, but it's at %q", scanner.Text())
+	}
+}
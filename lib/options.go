@@ -0,0 +1,34 @@
+package lib
+
+// Options control how files are converted.
+type Options struct {
+	// Lossless adds the raw values from the file (such as bits_raw and
+	// sector_raw) next to the human-readable names they were converted to.
+	Lossless bool
+	// Dialect holds the tables used to convert rooms, mobs, and zones.  If it
+	// is nil, the dialect is chosen based on the format of each record.
+	Dialect *Dialect
+	// KeepGoing keeps converting room, mob and zone files after an error,
+	// skipping to the next record in the file, and returns every error as an
+	// ErrorList at the end.
+	KeepGoing bool
+	// WritePartial writes the records that could be parsed from files with
+	// errors in keep going mode.  Otherwise nothing is written for them.
+	WritePartial bool
+	// Encoding is the encoding of the files being converted, or of the files
+	// written by ConvertJSONFiles.  If it is nil, the encoding of each file is
+	// detected from its contents, and ConvertJSONFiles uses the encoding
+	// recorded in the json (or UTF-8).
+	Encoding *Encoding
+	// Color says what to do with color codes in the text of rooms, mobs and
+	// objects.
+	Color ColorMode
+	// Warn, if set, is called with problems that don't stop a file from being
+	// converted, such as unknown color codes.
+	Warn func(err error)
+	// Jobs is how many room, mob or zone files are converted at once.  Files
+	// are still written, and errors reported, in the order they were given.
+	Jobs int
+	// Passwords keeps the password hashes in converted player files.
+	Passwords bool
+}
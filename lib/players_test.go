@@ -0,0 +1,100 @@
+package lib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePlayer(t *testing.T) {
+	plr := `Name: Fizban
+Pass: $1$Fizban$Yt8Cp1zSkZ9nTBYJ/N8uj.
+Titl: the Implementor
+Desc:
+A wizened old man.
+~
+Sex : 1
+Clas: 0
+Levl: 34
+Id  : 1
+Brth: 1230000000
+Plyd: 3600
+Last: 1230003600
+Host: 127.0.0.1
+Hite: 180
+Wate: 160
+Alin: 1000
+Act : e 0 0 0
+Aff : bc 0 0 0
+Pref: abc 0 0 0
+Skil:
+1 95
+34 50
+0 0
+Affs:
+34 24 0 0 0 0 0 0
+0 0 0 0 0 0 0 0
+Room: 3001
+Str : 18/100
+Int : 25
+Wis : 25
+Dex : 25
+Con : 25
+Cha : 25
+Hit : 500/500
+Mana: 100/100
+Move: 82/82
+Ac  : 100
+Gold: 1500
+Bank: 20000
+Exp : 7000000
+Hrol: 0
+Drol: 0
+`
+	name := filepath.Join(t.TempDir(), "fizban.plr")
+	if err := ioutil.WriteFile(name, []byte(plr), 0600); err != nil {
+		t.Fatal(err)
+	}
+	p, err := ParsePlayerFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Fizban" || p.ID != 1 || p.Level != 34 || p.Class != "Magic User" {
+		t.Errorf("unexpected player: %+v", p)
+	}
+	if p.Description != "A wizened old man.\n" {
+		t.Errorf("unexpected description: %q", p.Description)
+	}
+	if p.Str != 18 || p.StrAdd != 100 || p.Hit != 500 || p.MaxMove != 82 {
+		t.Errorf("unexpected stats: %+v", p)
+	}
+	if want := []string{"BLIND", "INVISIBLE"}; !reflect.DeepEqual(p.Affections, want) {
+		t.Errorf("expected affections %v, got %v", want, p.Affections)
+	}
+	if want := []Skill{{1, 95}, {34, 50}}; !reflect.DeepEqual(p.Skills, want) {
+		t.Errorf("expected skills %v, got %v", want, p.Skills)
+	}
+	if len(p.Affects) != 1 || p.Affects[0].Spell != 34 || p.Affects[0].Duration != 24 {
+		t.Errorf("unexpected affects: %+v", p.Affects)
+	}
+	if p.Room != 3001 {
+		t.Errorf("expected the tags after the skills to be read, got room %d", p.Room)
+	}
+	if p.Password != "$1$Fizban$Yt8Cp1zSkZ9nTBYJ/N8uj." || p.Extra["Pass"] != "" {
+		t.Errorf("expected the password to be read, got %q %v", p.Password, p.Extra)
+	}
+
+	to := t.TempDir()
+	if err := ConvertPlayerFiles(to, []string{name}, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(to, "fizban.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "Yt8Cp1zSkZ9nTBYJ") {
+		t.Errorf("expected the password to be left out of the json:\n%s", b)
+	}
+}
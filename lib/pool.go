@@ -0,0 +1,127 @@
+package lib
+
+import (
+	"sync"
+)
+
+// fileConverter converts a single file.  It returns the name to report the
+// file's errors with, and the errors from reading and parsing it, which don't
+// stop the other files from being converted in keep going mode.  The output is
+// closed but not yet committed, or nil if there's nothing to write.  Errors
+// writing the output are returned as err.
+type fileConverter func(to, name string, opts Options) (n string, perrs []error, out *output, err error)
+
+// fileResult is what a fileConverter returned, along with the warnings it
+// gave.
+type fileResult struct {
+	n        string
+	perrs    []error
+	out      *output
+	err      error
+	warnings []error
+}
+
+// convertFiles converts the files with convert, opts.Jobs at a time.  The
+// output is committed and the errors and warnings are reported in the order of
+// the files, so the results are the same however many files are converted at
+// once: when not in keep going mode, nothing after the first file with an
+// error is written.  Output to stdout is always converted one file at a time.
+func convertFiles(to string, files []string, opts Options, convert fileConverter) error {
+	if err := makeOutputDir(to); err != nil {
+		return err
+	}
+	errs := &errorCollector{keepGoing: opts.KeepGoing}
+	if opts.Jobs <= 1 || to == Stdio {
+		for _, name := range files {
+			n, perrs, out, err := convert(to, name, opts)
+			if err := finishFile(fileResult{n: n, perrs: perrs, out: out, err: err}, opts, errs); err != nil {
+				return err
+			}
+		}
+		return errs.err()
+	}
+
+	results := make([]chan fileResult, len(files))
+	for i := range results {
+		results[i] = make(chan fileResult, 1)
+	}
+	next := make(chan int)
+	stop := make(chan struct{})
+	go func() {
+		defer close(next)
+		for i := range files {
+			select {
+			case next <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	wg := &sync.WaitGroup{}
+	for j := 0; j < opts.Jobs; j++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				// hold on to the warnings, so they come out in order.
+				var res fileResult
+				fileOpts := opts
+				if opts.Warn != nil {
+					fileOpts.Warn = func(err error) {
+						res.warnings = append(res.warnings, err)
+					}
+				}
+				res.n, res.perrs, res.out, res.err = convert(to, files[i], fileOpts)
+				results[i] <- res
+			}
+		}()
+	}
+
+	err := func() error {
+		for _, r := range results {
+			if err := finishFile(<-r, opts, errs); err != nil {
+				return err
+			}
+		}
+		return errs.err()
+	}()
+	close(stop)
+	// throw away whatever was converted after the file that stopped us.
+	wg.Wait()
+	for _, r := range results {
+		select {
+		case res := <-r:
+			if res.out != nil {
+				res.out.discard()
+			}
+		default:
+		}
+	}
+	return err
+}
+
+// finishFile reports the warnings and errors from converting a file, and
+// commits its output.  It returns an error if conversion should stop.
+func finishFile(res fileResult, opts Options, errs *errorCollector) error {
+	for _, w := range res.warnings {
+		opts.Warn(w)
+	}
+	if res.err != nil {
+		if res.out != nil {
+			res.out.discard()
+		}
+		return res.err
+	}
+	if len(res.perrs) > 0 {
+		if err := errs.add(res.n, res.perrs...); err != nil {
+			if res.out != nil {
+				res.out.discard()
+			}
+			return err
+		}
+	}
+	if res.out == nil {
+		return nil
+	}
+	return res.out.commit()
+}
@@ -0,0 +1,94 @@
+package lib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestConvertRoomFilesJobs(t *testing.T) {
+	from := t.TempDir()
+	var files []string
+	for i := 0; i < 20; i++ {
+		wld := fmt.Sprintf("#%d\nRoom~\nA room.~\n1 0 0\nS\n$\n", i)
+		if i == 5 || i == 12 {
+			wld = fmt.Sprintf("#%d\nRoom~\nA room.~\n1 0\nS\n$\n", i)
+		}
+		name := filepath.Join(from, fmt.Sprintf("%02d.wld", i))
+		if err := ioutil.WriteFile(name, []byte(wld), 0600); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, name)
+	}
+
+	// without keep going, nothing after the first error should be written,
+	// however many files are converted at once.
+	to := t.TempDir()
+	err := ConvertRoomFiles(to, files, Options{Jobs: 8})
+	if err == nil || err.Error() != filepath.Join(from, "05.wld")+`:4 - expected room metadata to be <zone#> <bitvector> <sector>, but got "1 0"` {
+		t.Errorf("expected an error from 05.wld, got %v", err)
+	}
+	if names := dirNames(t, to); !reflect.DeepEqual(names, []string{"00.json", "01.json", "02.json", "03.json", "04.json"}) {
+		t.Errorf("expected the files before 05.wld to be written, got %v", names)
+	}
+
+	to = t.TempDir()
+	err = ConvertRoomFiles(to, files, Options{Jobs: 8, KeepGoing: true})
+	list, ok := err.(ErrorList)
+	if !ok || len(list) != 2 || list[0].File != files[5] || list[1].File != files[12] {
+		t.Errorf("expected errors from 05.wld and 12.wld, got %v", err)
+	}
+	if names := dirNames(t, to); len(names) != 18 {
+		t.Errorf("expected 18 files to be written, got %v", names)
+	}
+}
+
+func TestConvertRoomFilesStdout(t *testing.T) {
+	from := t.TempDir()
+	good := filepath.Join(from, "1.wld")
+	bad := filepath.Join(from, "2.wld")
+	if err := ioutil.WriteFile(good, []byte("#1\nRoom~\nA room.~\n1 0 0\nS\n$\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(bad, []byte("#2\nRoom~\nA room.~\n1 0 0\nS\n#3\nRoom~\nA room.~\n1 0\nS\n$\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	err = ConvertRoomFiles(Stdio, []string{good, bad}, Options{KeepGoing: true})
+	os.Stdout = stdout
+	w.Close()
+	if list, ok := err.(ErrorList); !ok || len(list) != 1 {
+		t.Errorf("expected an error from 2.wld, got %v", err)
+	}
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"number": 1,`) || strings.Contains(string(b), `"number": 2,`) {
+		t.Errorf("expected only the rooms from 1.wld on stdout, got\n%s", b)
+	}
+}
+
+func dirNames(t *testing.T, dir string) []string {
+	f, err := os.Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	names, err := f.Readdirnames(-1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,76 @@
+package lib
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// recordWriter writes the json for a list of records one record at a time, so
+// that they don't all have to be in memory at once.  The json is an object
+// with the records in a single field, laid out the same way as
+// json.MarshalIndent would.
+type recordWriter struct {
+	w     io.Writer
+	count int
+}
+
+// jsonField is a string field written after the records.
+type jsonField struct {
+	name, value string
+}
+
+// newRecordWriter starts writing an object with the records in the named
+// field to w.
+func newRecordWriter(w io.Writer, field string) (*recordWriter, error) {
+	if _, err := fmt.Fprintf(w, "{\n    %q: [", field); err != nil {
+		return nil, err
+	}
+	return &recordWriter{w: w}, nil
+}
+
+// Write writes the next record.
+func (rw *recordWriter) Write(v interface{}) error {
+	b, err := json.MarshalIndent(v, "        ", "    ")
+	if err != nil {
+		return err
+	}
+	sep := ",\n        "
+	if rw.count == 0 {
+		sep = "\n        "
+	}
+	if _, err := io.WriteString(rw.w, sep); err != nil {
+		return err
+	}
+	if _, err := rw.w.Write(b); err != nil {
+		return err
+	}
+	rw.count++
+	return nil
+}
+
+// Close ends the list of records, and writes the fields that have a value
+// after it.
+func (rw *recordWriter) Close(fields ...jsonField) error {
+	end := "\n    ]"
+	if rw.count == 0 {
+		end = "]"
+	}
+	if _, err := io.WriteString(rw.w, end); err != nil {
+		return err
+	}
+	for _, f := range fields {
+		if f.value == "" {
+			continue
+		}
+		b, err := json.Marshal(f.value)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(rw.w, ",\n    %q: %s", f.name, b); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(rw.w, "\n}")
+	return err
+}
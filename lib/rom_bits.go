@@ -0,0 +1,252 @@
+package lib
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The ROM flag tables are indexed by bit number.  ROM writes flags as letters
+// where A is bit 0, Z is bit 25 and a is bit 26, or as plain numbers (which is
+// how Merc writes them).
+
+// RomRoomFlags are the names of ROM 2.4's room flags.
+var RomRoomFlags = []string{
+	"DARK",
+	"",
+	"NO_MOB",
+	"INDOORS",
+	"",
+	"",
+	"",
+	"",
+	"",
+	"PRIVATE",
+	"SAFE",
+	"SOLITARY",
+	"PET_SHOP",
+	"NO_RECALL",
+	"IMP_ONLY",
+	"GODS_ONLY",
+	"HEROES_ONLY",
+	"NEWBIES_ONLY",
+	"LAW",
+	"NOWHERE",
+}
+
+// RomActFlags are the names of ROM 2.4's mob act flags.
+var RomActFlags = []string{
+	"IS_NPC",
+	"SENTINEL",
+	"SCAVENGER",
+	"",
+	"",
+	"AGGRESSIVE",
+	"STAY_AREA",
+	"WIMPY",
+	"PET",
+	"TRAIN",
+	"PRACTICE",
+	"",
+	"",
+	"",
+	"UNDEAD",
+	"",
+	"CLERIC",
+	"MAGE",
+	"THIEF",
+	"WARRIOR",
+	"NOALIGN",
+	"NOPURGE",
+	"OUTDOORS",
+	"",
+	"INDOORS",
+	"",
+	"IS_HEALER",
+	"GAIN",
+	"UPDATE_ALWAYS",
+	"IS_CHANGER",
+}
+
+// RomAffectFlags are the names of ROM 2.4's affect flags.
+var RomAffectFlags = []string{
+	"BLIND",
+	"INVISIBLE",
+	"DETECT_EVIL",
+	"DETECT_INVIS",
+	"DETECT_MAGIC",
+	"DETECT_HIDDEN",
+	"DETECT_GOOD",
+	"SANCTUARY",
+	"FAERIE_FIRE",
+	"INFRARED",
+	"CURSE",
+	"",
+	"POISON",
+	"PROTECT_EVIL",
+	"PROTECT_GOOD",
+	"SNEAK",
+	"HIDE",
+	"SLEEP",
+	"CHARM",
+	"FLYING",
+	"PASS_DOOR",
+	"HASTE",
+	"CALM",
+	"PLAGUE",
+	"WEAKEN",
+	"DARK_VISION",
+	"BERSERK",
+	"SWIM",
+	"REGENERATION",
+	"SLOW",
+}
+
+// RomSectors is the conversion between ROM's sector type number and its name.
+var RomSectors = map[string]string{
+	"0":  "INSIDE",
+	"1":  "CITY",
+	"2":  "FIELD",
+	"3":  "FOREST",
+	"4":  "HILLS",
+	"5":  "MOUNTAIN",
+	"6":  "WATER_SWIM",
+	"7":  "WATER_NOSWIM",
+	"8":  "UNUSED",
+	"9":  "AIR",
+	"10": "DESERT",
+}
+
+// RomPositions is the conversion between ROM's position names and
+// CircleMUD's.
+var RomPositions = map[string]string{
+	"dead":  "POSITION_DEAD",
+	"mort":  "POSITION_MORTALLYW",
+	"incap": "POSITION_INCAP",
+	"stun":  "POSITION_STUNNED",
+	"sleep": "POSITION_SLEEPING",
+	"rest":  "POSITION_RESTING",
+	"sit":   "POSITION_SITTING",
+	"fight": "POSITION_FIGHTING",
+	"stand": "POSITION_STANDING",
+}
+
+// RomSexes is the conversion between ROM's sex names and CircleMUD's genders.
+// ROM's "either" (chosen randomly when the mob loads) has no equivalent.
+var RomSexes = map[string]string{
+	"none":   "Neutral",
+	"male":   "Male",
+	"female": "Female",
+}
+
+// The CircleMUD names of the ROM flags and sectors that have an equivalent.
+var (
+	romRoomFlagsToCircle = map[string]string{
+		"DARK":      "DARK",
+		"NO_MOB":    "NOMOB",
+		"INDOORS":   "INDOORS",
+		"PRIVATE":   "PRIVATE",
+		"SAFE":      "PEACEFUL",
+		"SOLITARY":  "TUNNEL",
+		"GODS_ONLY": "GODROOM",
+	}
+	romActFlagsToCircle = map[string]string{
+		"SENTINEL":   "SENTINEL",
+		"SCAVENGER":  "SCAVENGER",
+		"AGGRESSIVE": "AGGRESSIVE",
+		"STAY_AREA":  "STAY_ZONE",
+		"WIMPY":      "WIMPY",
+	}
+	romAffectFlagsToCircle = map[string]string{
+		"BLIND":        "BLIND",
+		"INVISIBLE":    "INVISIBLE",
+		"DETECT_EVIL":  "DETECT_ALIGN",
+		"DETECT_GOOD":  "DETECT_ALIGN",
+		"DETECT_INVIS": "DETECT_INVIS",
+		"DETECT_MAGIC": "DETECT_MAGIC",
+		"SANCTUARY":    "SANCTUARY",
+		"CURSE":        "CURSE",
+		"INFRARED":     "INFRAVISION",
+		"POISON":       "POISON",
+		"PROTECT_EVIL": "PROTECT_EVIL",
+		"PROTECT_GOOD": "PROTECT_GOOD",
+		"SNEAK":        "SNEAK",
+		"HIDE":         "HIDE",
+		"SLEEP":        "SLEEP",
+		"CHARM":        "CHARM",
+	}
+	romSectorsToCircle = map[string]string{
+		"INSIDE":       "INSIDE",
+		"CITY":         "CITY",
+		"FIELD":        "FIELD",
+		"FOREST":       "FOREST",
+		"HILLS":        "HILLS",
+		"MOUNTAIN":     "MOUNTAIN",
+		"WATER_SWIM":   "WATER_SWIM",
+		"WATER_NOSWIM": "WATER_NOSWIM",
+		"AIR":          "FLYING",
+	}
+	// romSectorsNearest are the nearest CircleMUD sectors for the ROM sectors
+	// with no equivalent.
+	romSectorsNearest = map[string]string{
+		"UNUSED": "INSIDE",
+		"DESERT": "FIELD",
+	}
+)
+
+// RomFlagsToNames converts ROM flags (letters, a number, or several of either
+// joined with |) into a list of bit names.  Bits that have no name are listed
+// as BIT_n.
+func RomFlagsToNames(flags string, names []string) ([]string, error) {
+	var bits uint64
+	for _, part := range strings.Split(flags, "|") {
+		if part == "" {
+			return nil, fmt.Errorf("invalid flags %q", flags)
+		}
+		if n, err := strconv.ParseUint(part, 10, 64); err == nil {
+			bits |= n
+			continue
+		}
+		for _, c := range part {
+			switch {
+			case c >= 'A' && c <= 'Z':
+				bits |= 1 << uint(c-'A')
+			case c >= 'a' && c <= 'z':
+				bits |= 1 << uint(26+c-'a')
+			default:
+				return nil, fmt.Errorf("invalid flags %q", flags)
+			}
+		}
+	}
+	values := []string{}
+	for n := uint(0); n < 64; n++ {
+		if bits&(1<<n) == 0 {
+			continue
+		}
+		if n < uint(len(names)) && names[n] != "" {
+			values = append(values, names[n])
+		} else {
+			values = append(values, unknownBitName(n))
+		}
+	}
+	return values, nil
+}
+
+// romToCircle splits ROM flag names into the CircleMUD names of the ones that
+// have an equivalent, and the ROM names of the ones that don't.
+func romToCircle(names []string, equivalents map[string]string) (circle, rom []string) {
+	circle = []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		c, ok := equivalents[name]
+		if !ok {
+			rom = append(rom, name)
+			continue
+		}
+		if !seen[c] {
+			seen[c] = true
+			circle = append(circle, c)
+		}
+	}
+	return circle, rom
+}
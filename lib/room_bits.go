@@ -61,5 +61,5 @@ var RoomBits = map[int]string{
 
 // BitVectorToNames converts a room's bitvector into a list of bit names
 func BitVectorToNames(vector string) ([]string, error) {
-	return BitsToNames(vector, ROOM_BFS_MARK, RoomBits, RoomChars)
+	return AllBitsToNames(vector, RoomBits, RoomChars)
 }
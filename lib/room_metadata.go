@@ -15,13 +15,38 @@ var SectorType = map[string]string{
 }
 
 // ExitDir is the conversion between CircleMUD's direction number and a human-readable string.
+// Directions 6 through 11 are the extended directions used by tbaMUD and
+// other MUDs with diagonal exits.
 var ExitDir = map[string]string{
-	"0": "North",
-	"1": "East",
-	"2": "South",
-	"3": "West",
-	"4": "Up",
-	"5": "Down",
+	"0":  "North",
+	"1":  "East",
+	"2":  "South",
+	"3":  "West",
+	"4":  "Up",
+	"5":  "Down",
+	"6":  "Northwest",
+	"7":  "Northeast",
+	"8":  "Southeast",
+	"9":  "Southwest",
+	"10": "In",
+	"11": "Out",
+}
+
+// OppositeDir is the direction that leads back the way an exit came, like
+// rev_dir in CircleMUD.
+var OppositeDir = map[string]string{
+	"North":     "South",
+	"East":      "West",
+	"South":     "North",
+	"West":      "East",
+	"Up":        "Down",
+	"Down":      "Up",
+	"Northwest": "Southeast",
+	"Northeast": "Southwest",
+	"Southeast": "Northwest",
+	"Southwest": "Northeast",
+	"In":        "Out",
+	"Out":       "In",
 }
 
 // DoorFlags is the conversion between CircleMUD's door flags and a human-readable string.
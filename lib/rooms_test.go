@@ -0,0 +1,206 @@
+package lib
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRooms(t *testing.T) {
+	rooms, err := ParseRooms(strings.NewReader("#3002\nThe Bank~\nA bank.~\n30 0 1\nS\n$\n"), "bank.wld")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rooms) != 1 || rooms[0].Name != "The Bank" {
+		t.Errorf("unexpected rooms: %+v", rooms)
+	}
+
+	_, err = ParseRooms(strings.NewReader("#3002\nThe Bank\n"), "bank.wld")
+	if err == nil {
+		t.Fatal("expected an error for a room name without ~")
+	}
+	if !strings.HasPrefix(err.Error(), "bank.wld:2 - ") {
+		t.Errorf("expected error to start with the name and line number, got %q", err)
+	}
+}
+
+func TestParseRoomsError(t *testing.T) {
+	wld := "#3001\nThe Temple~\nA temple.~\n30 0 0\nS\n#3002\nThe Bank~\nA bank.~\n30 0\nS\n$\n"
+	_, err := ParseRooms(strings.NewReader(wld), "30.wld")
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected a *ParseError, got %#v", err)
+	}
+	if perr.File != "30.wld" || perr.Line != 9 || perr.Record != 3002 || perr.Text != "30 0" {
+		t.Errorf("unexpected error location: %+v", perr)
+	}
+	if perr.Expected != "room metadata to be <zone#> <bitvector> <sector>" {
+		t.Errorf("unexpected expected element: %q", perr.Expected)
+	}
+	if err.Error() != `30.wld:9 - expected room metadata to be <zone#> <bitvector> <sector>, but got "30 0"` {
+		t.Errorf("unexpected error message: %q", err)
+	}
+}
+
+func TestParseRoomsErrorAfterRecord(t *testing.T) {
+	wld := "#10\nThe Temple~\nA temple.~\n30 0 0\nS\n#1x\nThe Bank~\nA bank.~\n30 0 0\nS\n$\n"
+	_, err := ParseRooms(strings.NewReader(wld), "30.wld")
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected a *ParseError, got %#v", err)
+	}
+	if perr.Line != 6 || perr.Record != -1 {
+		t.Errorf("expected an error on line 6 outside any record, got %+v", perr)
+	}
+}
+
+func TestParseRoomsKeepGoing(t *testing.T) {
+	wld := `#1
+A~
+A.
+~
+0 0 0
+S
+#2
+B~
+B.
+~
+0 0
+S
+#3
+C~
+C.
+~
+0 0 0
+X
+#4
+D~
+D.
+~
+0 0 0
+S
+$
+`
+	rooms, _, errs := (*Dialect)(nil).parseRooms(strings.NewReader(wld), "a.wld", true)
+	if len(rooms) != 2 || rooms[0].Number != 1 || rooms[1].Number != 4 {
+		t.Errorf("expected rooms 1 and 4, got %+v", rooms)
+	}
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 errors, got %v", errs)
+	}
+	var perr *ParseError
+	if !errors.As(errs[1], &perr) || perr.Record != 3 || perr.Line != 18 {
+		t.Errorf("expected second error in room 3 on line 18, got %v", errs[1])
+	}
+}
+
+func TestParseRoomsCRLF(t *testing.T) {
+	wld := "#3002\r\nThe Bank~ \r\nA bank.\r\nWith money.~\r\n30 0 1\r\nD0\r\n~\r\ndoor~\t\r\n1 -1 3001\r\nS\r\n$\r\n"
+	rooms, crlf, errs := (*Dialect)(nil).parseRooms(strings.NewReader(wld), "bank.wld", false)
+	if len(errs) > 0 {
+		t.Fatal(errs[0])
+	}
+	if !crlf {
+		t.Error("expected the file to be detected as using \\r\\n line endings")
+	}
+	if len(rooms) != 1 {
+		t.Fatalf("expected 1 room, got %+v", rooms)
+	}
+	r := rooms[0]
+	if r.Name != "The Bank" || r.Description != "A bank.\nWith money." {
+		t.Errorf("unexpected name or description: %q %q", r.Name, r.Description)
+	}
+	if len(r.Exits) != 1 || len(r.Exits[0].Keywords) != 1 || r.Exits[0].Keywords[0] != "door" {
+		t.Errorf("unexpected exits: %+v", r.Exits)
+	}
+
+	_, crlf, _ = (*Dialect)(nil).parseRooms(strings.NewReader("#3002\nThe Bank~\nA bank.~\n30 0 1\nS\n$\n"), "bank.wld", false)
+	if crlf {
+		t.Error("expected the file to be detected as using \\n line endings")
+	}
+}
+
+func TestRoomReader(t *testing.T) {
+	wld := "#1\nA~\nA.~\n0 0 0\nS\n#2\nB~\nB.~\n0 0\nS\n#3\nC~\nC.~\n0 0 0\nS\n$\n"
+	rr := NewRoomReader(strings.NewReader(wld), "a.wld")
+	var rooms []Room
+	var errs []error
+	for {
+		r, err := rr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		rooms = append(rooms, *r)
+	}
+	if len(rooms) != 2 || rooms[0].Number != 1 || rooms[1].Number != 3 {
+		t.Errorf("expected rooms 1 and 3, got %+v", rooms)
+	}
+	if len(errs) != 1 {
+		t.Errorf("expected 1 error, got %v", errs)
+	}
+
+	// the rooms written one at a time should look just like MarshalIndent's.
+	buf := &bytes.Buffer{}
+	w, err := newRecordWriter(buf, "rooms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range rooms {
+		if err := w.Write(&rooms[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(jsonField{"line_ending", "crlf"}, jsonField{"encoding", ""}); err != nil {
+		t.Fatal(err)
+	}
+	expected, err := json.MarshalIndent(map[string]interface{}{"rooms": rooms, "line_ending": "crlf"}, "", "    ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, want interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid json %q: %v", buf, err)
+	}
+	json.Unmarshal(expected, &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %s, got %s", expected, buf)
+	}
+}
+
+// panicReader returns its text and then panics, like a bufio.Scanner that's
+// been sent too much.
+type panicReader struct {
+	text string
+}
+
+func (p *panicReader) Read(b []byte) (int, error) {
+	if p.text == "" {
+		panic("too much stuff")
+	}
+	n := copy(b, p.text)
+	p.text = p.text[n:]
+	return n, nil
+}
+
+func TestRoomReaderPanic(t *testing.T) {
+	rr := NewRoomReader(&panicReader{text: "#1\nA~\n"}, "a.wld")
+	_, err := rr.Next()
+	var perr *ParseError
+	if !errors.As(err, &perr) {
+		t.Fatalf("expected a *ParseError, got %#v", err)
+	}
+	if perr.File != "a.wld" || perr.Record != 1 || err.Error() != "a.wld:2 - too much stuff" {
+		t.Errorf("unexpected error: %+v", perr)
+	}
+	if _, err := rr.Next(); err != io.EOF {
+		t.Errorf("expected io.EOF after a panic, got %v", err)
+	}
+}
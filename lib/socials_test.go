@@ -0,0 +1,40 @@
+package lib
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestParseSocial(t *testing.T) {
+	r := strings.NewReader(
+		`bounce 0 0
+
+$n bounces around.
+#
+
+$
+`)
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	if err := scanner.MustScan(); err != nil {
+		t.Fatal(err)
+	}
+	soc, err := scanSocial(scanner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if soc.CharNoArg == nil || *soc.CharNoArg != "" {
+		t.Errorf("expected empty char_no_arg message, got %v", soc.CharNoArg)
+	}
+	if soc.OthersNoArg == nil || *soc.OthersNoArg != "$n bounces around." {
+		t.Errorf("expected others_no_arg message, got %v", soc.OthersNoArg)
+	}
+	if soc.CharFound != nil {
+		t.Errorf("expected no char_found message, got %q", *soc.CharFound)
+	}
+}
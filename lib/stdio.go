@@ -0,0 +1,204 @@
+package lib
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// Stdio may be given to the ConvertXFiles functions in place of an input file
+// name, to read from stdin, or in place of the output directory, to write to
+// stdout.
+const Stdio = "-"
+
+// detectSize is how much of the start of a file is used to detect its
+// encoding.
+const detectSize = 64 * 1024
+
+// openInput opens the named input file, or stdin if the name is Stdio.  It
+// also returns the name to use for the file in error messages.
+func openInput(name string) (io.ReadCloser, string, error) {
+	if name == Stdio {
+		return ioutil.NopCloser(os.Stdin), "stdin", nil
+	}
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, "", err
+	}
+	return f, name, nil
+}
+
+// readInput opens the named input file (or stdin, if the name is Stdio), and
+// decodes what's read from it from enc, or from the encoding detected from the
+// start of the file if enc is nil.  It also returns the name to use for the
+// file in error messages, and the encoding that was used.
+func readInput(name string, enc *Encoding) (io.ReadCloser, string, *Encoding, error) {
+	f, n, err := openInput(name)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	r := bufio.NewReaderSize(f, detectSize)
+	if enc == nil {
+		b, err := r.Peek(detectSize)
+		if err != nil && err != io.EOF {
+			f.Close()
+			return nil, "", nil, err
+		}
+		enc = DetectEncoding(b)
+		if enc == UTF8 && len(b) == detectSize {
+			// only the start of the file was checked, so check the rest as
+			// it's read.
+			return decodedInput{&utf8Checker{r: r}, f}, n, enc, nil
+		}
+	}
+	return decodedInput{enc.NewDecoder(r), f}, n, enc, nil
+}
+
+// utf8Checker returns an error if what's read from r isn't valid UTF-8.
+type utf8Checker struct {
+	r io.Reader
+	// partial is the start of a character that was cut off at the end of the
+	// last read.
+	partial []byte
+	offset  int64
+}
+
+func (c *utf8Checker) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	b := append(c.partial, p[:n]...)
+	c.partial = nil
+	if err == nil {
+		for i := 1; i <= utf8.UTFMax && i <= len(b); i++ {
+			if utf8.RuneStart(b[len(b)-i]) {
+				if !utf8.FullRune(b[len(b)-i:]) {
+					c.partial = append([]byte(nil), b[len(b)-i:]...)
+					b = b[:len(b)-i]
+				}
+				break
+			}
+		}
+	}
+	if !utf8.Valid(b) {
+		for i := 0; i < len(b); {
+			r, size := utf8.DecodeRune(b[i:])
+			if r == utf8.RuneError && size <= 1 {
+				return 0, fmt.Errorf("invalid UTF-8 at byte %d, after the part of the file used to detect its encoding; pass -encoding to say what it is", c.offset+int64(i))
+			}
+			i += size
+		}
+	}
+	c.offset += int64(len(b))
+	return n, err
+}
+
+// decodedInput reads from the decoder, and closes the file it's reading from.
+type decodedInput struct {
+	io.Reader
+	io.Closer
+}
+
+// makeOutputDir creates the output directory, unless the output is going to
+// stdout.
+func makeOutputDir(to string) error {
+	if to == Stdio {
+		return nil
+	}
+	if err := os.MkdirAll(to, 0700); err != nil {
+		return fmt.Errorf("couldn't create output directory: %v", err)
+	}
+	return nil
+}
+
+// outputPath returns the path of the file in the to directory that what's
+// converted from the named input file is written to.
+func outputPath(to, name, ext string) string {
+	n := "stdin"
+	if name != Stdio {
+		n = filepath.Base(name)
+		n = n[:len(n)-len(filepath.Ext(n))]
+	}
+	return filepath.Join(to, n+ext)
+}
+
+// writeOutput writes what was converted from the named input file to a file
+// in the to directory with the same name and the given extension, or to
+// stdout if to is Stdio.
+func writeOutput(to, name, ext string, b []byte) error {
+	if to == Stdio {
+		if len(b) > 0 && b[len(b)-1] != '\n' {
+			b = append(b, '\n')
+		}
+		_, err := os.Stdout.Write(b)
+		return err
+	}
+	return ioutil.WriteFile(outputPath(to, name, ext), b, 0600)
+}
+
+// output is like writeOutput, for output that's written a piece at a time.  It
+// writes to a temporary file, which only replaces the real one (or is copied to
+// stdout) when the output is committed, so that files that fail to convert
+// aren't left half written.
+type output struct {
+	*bufio.Writer
+	// f is the temporary file.
+	f *os.File
+	// path is where the output goes, or "" for stdout.
+	path string
+}
+
+// createOutput starts the output for the named input file, in the to
+// directory with the given extension, or to stdout if to is Stdio.
+func createOutput(to, name, ext string) (*output, error) {
+	if to == Stdio {
+		f, err := ioutil.TempFile("", "circle2json.*")
+		if err != nil {
+			return nil, err
+		}
+		return &output{Writer: bufio.NewWriterSize(f, detectSize), f: f}, nil
+	}
+	path := outputPath(to, name, ext)
+	f, err := ioutil.TempFile(to, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, err
+	}
+	return &output{Writer: bufio.NewWriterSize(f, detectSize), f: f, path: path}, nil
+}
+
+// close finishes writing the output.
+func (o *output) close() error {
+	err := o.Flush()
+	if o.path == "" {
+		// keep the file open to copy it to stdout.
+		return err
+	}
+	if cerr := o.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// commit moves the closed output into place.
+func (o *output) commit() error {
+	if o.path == "" {
+		defer o.discard()
+		if _, err := o.f.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if _, err := io.Copy(os.Stdout, o.f); err != nil {
+			return err
+		}
+		_, err := os.Stdout.Write([]byte("\n"))
+		return err
+	}
+	return os.Rename(o.f.Name(), o.path)
+}
+
+// discard throws the output away.
+func (o *output) discard() {
+	o.f.Close()
+	os.Remove(o.f.Name())
+}
@@ -0,0 +1,140 @@
+package lib
+
+// The tbaMUD flag tables are indexed by bit number, the same way tbaMUD's
+// constants.c defines them, since its 128 bit flags don't fit in an int.
+
+// TbaRoomFlags are the names of tbaMUD's room flags.
+var TbaRoomFlags = []string{
+	"DARK",
+	"DEATH",
+	"NOMOB",
+	"INDOORS",
+	"PEACEFUL",
+	"SOUNDPROOF",
+	"NOTRACK",
+	"NOMAGIC",
+	"TUNNEL",
+	"PRIVATE",
+	"GODROOM",
+	"HOUSE",
+	"HOUSE_CRASH",
+	"ATRIUM",
+	"OLC",
+	"BFS_MARK",
+	"WORLDMAP",
+}
+
+// TbaMobFlags are the names of tbaMUD's mob action flags.
+var TbaMobFlags = []string{
+	"SPEC",
+	"SENTINEL",
+	"SCAVENGER",
+	"ISNPC",
+	"AWARE",
+	"AGGRESSIVE",
+	"STAY_ZONE",
+	"WIMPY",
+	"AGGR_EVIL",
+	"AGGR_GOOD",
+	"AGGR_NEUTRAL",
+	"MEMORY",
+	"HELPER",
+	"NOCHARM",
+	"NOSUMMON",
+	"NOSLEEP",
+	"NOBASH",
+	"NOBLIND",
+	"NOKILL",
+	"NOTDEADYET",
+}
+
+// TbaAffectFlags are the names of tbaMUD's affect flags.  Unlike CircleMUD,
+// bit 0 is unused.
+var TbaAffectFlags = []string{
+	"DONTUSE",
+	"BLIND",
+	"INVISIBLE",
+	"DETECT_ALIGN",
+	"DETECT_INVIS",
+	"DETECT_MAGIC",
+	"SENSE_LIFE",
+	"WATERWALK",
+	"SANCTUARY",
+	"GROUP",
+	"CURSE",
+	"INFRAVISION",
+	"POISON",
+	"PROTECT_EVIL",
+	"PROTECT_GOOD",
+	"SLEEP",
+	"NOTRACK",
+	"FLYING",
+	"SCUBA",
+	"SNEAK",
+	"HIDE",
+	"FREE",
+	"CHARM",
+}
+
+// TbaPlayerFlags are the names of tbaMUD's player (PLR) flags.
+var TbaPlayerFlags = []string{
+	"KILLER",
+	"THIEF",
+	"FROZEN",
+	"DONTSET",
+	"WRITING",
+	"MAILING",
+	"CRASH",
+	"SITEOK",
+	"NOSHOUT",
+	"NOTITLE",
+	"DELETED",
+	"LOADROOM",
+	"NOWIZLIST",
+	"NODELETE",
+	"INVSTART",
+	"CRYO",
+	"NOTDEADYET",
+	"BUG",
+	"IDEA",
+	"TYPO",
+}
+
+// TbaPrefFlags are the names of tbaMUD's player preference (PRF) flags.
+var TbaPrefFlags = []string{
+	"BRIEF",
+	"COMPACT",
+	"NOSHOUT",
+	"NOTELL",
+	"DISPHP",
+	"DISPMANA",
+	"DISPMOVE",
+	"AUTOEXIT",
+	"NOHASSLE",
+	"QUEST",
+	"SUMMONABLE",
+	"NOREPEAT",
+	"HOLYLIGHT",
+	"COLOR_1",
+	"COLOR_2",
+	"NOWIZ",
+	"LOG1",
+	"LOG2",
+	"NOAUCT",
+	"NOGOSS",
+	"NOGRATZ",
+	"SHOWVNUMS",
+	"DISPAUTO",
+	"CLS",
+	"BUILDWALK",
+	"AFK",
+	"AUTOLOOT",
+	"AUTOGOLD",
+	"AUTOSPLIT",
+	"AUTOSAC",
+	"AUTOASSIST",
+	"AUTOMAP",
+	"AUTOKEY",
+	"AUTODOOR",
+	"ZONERESETS",
+}
@@ -0,0 +1,73 @@
+package lib
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// tbaMUD files converted without a dialect or lossless mode have to remember
+// that they were tbaMUD, or they're written back as CircleMUD, where sector 8
+// is a different sector and the flags are laid out differently.
+func TestTbaRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	wld := filepath.Join(dir, "1.wld")
+	if err := ioutil.WriteFile(wld, []byte("#100\nThe Sky~\nUp high.\n~\n1 d 0 0 0 8\nS\n$\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mob := filepath.Join(dir, "2.mob")
+	if err := ioutil.WriteFile(mob, []byte(`#100
+guard~
+the guard~
+A guard stands here.
+~
+~
+bdF 0 0 0 cD 0 0 0 500 S
+1 20 10 1d1+10 1d4+0
+10 100
+8 8 1
+$
+`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	jsonDir := filepath.Join(dir, "json")
+	if err := ConvertRoomFiles(jsonDir, []string{wld}, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ConvertMobFiles(jsonDir, []string{mob}, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	backDir := filepath.Join(dir, "back")
+	files, _ := filepath.Glob(filepath.Join(jsonDir, "*.json"))
+	if err := ConvertJSONFiles(backDir, files, Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	rooms, err := ParseWldFile(wld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rooms2, err := ParseWldFile(filepath.Join(backDir, "1.wld"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rooms2[0].Sector != "FLYING" || rooms2[0].BitsRaw != "d 0 0 0" {
+		t.Errorf("expected a tbaMUD FLYING room, got %+v", rooms2[0])
+	}
+	if !reflect.DeepEqual(rooms, rooms2) {
+		t.Errorf("round trip changed the rooms:\n%+v\n%+v", rooms, rooms2)
+	}
+
+	mobs, err := ParseMobFile(mob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mobs2, err := ParseMobFile(filepath.Join(backDir, "2.mob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(mobs, mobs2) {
+		t.Errorf("round trip changed the mobs:\n%+v\n%+v", mobs[0], mobs2[0])
+	}
+}
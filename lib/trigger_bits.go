@@ -0,0 +1,161 @@
+package lib
+
+// Trigger attach types.
+const (
+	MOB_TRIGGER   = "MOB"
+	OBJ_TRIGGER   = "OBJ"
+	WORLD_TRIGGER = "WLD"
+)
+
+// TriggerAttachTypes is the conversion between DG Scripts' attach type number and a human-readable string.
+var TriggerAttachTypes = map[string]string{
+	"0": MOB_TRIGGER,
+	"1": OBJ_TRIGGER,
+	"2": WORLD_TRIGGER,
+}
+
+// The meaning of a trigger's type bits depends on what it is attached to, so
+// each attach type has its own tables.
+const (
+	TRIG_GLOBAL int = 1 << iota
+	TRIG_RANDOM
+	TRIG_COMMAND
+	TRIG_BIT3
+	TRIG_BIT4
+	TRIG_BIT5
+	TRIG_BIT6
+	TRIG_BIT7
+	TRIG_BIT8
+	TRIG_BIT9
+	TRIG_BIT10
+	TRIG_BIT11
+	TRIG_BIT12
+	TRIG_BIT13
+	TRIG_BIT14
+	TRIG_BIT15
+	TRIG_BIT16
+	TRIG_BIT17
+	TRIG_BIT18
+	TRIG_BIT19
+)
+
+var MobTriggerChars = map[rune]string{
+	'a': "GLOBAL",
+	'b': "RANDOM",
+	'c': "COMMAND",
+	'd': "SPEECH",
+	'e': "ACT",
+	'f': "DEATH",
+	'g': "GREET",
+	'h': "GREET_ALL",
+	'i': "ENTRY",
+	'j': "RECEIVE",
+	'k': "FIGHT",
+	'l': "HITPRCNT",
+	'm': "BRIBE",
+	'n': "LOAD",
+	'o': "MEMORY",
+	'p': "CAST",
+	'q': "LEAVE",
+	'r': "DOOR",
+	't': "TIME",
+}
+
+var MobTriggerBits = map[int]string{
+	TRIG_GLOBAL:  "GLOBAL",
+	TRIG_RANDOM:  "RANDOM",
+	TRIG_COMMAND: "COMMAND",
+	TRIG_BIT3:    "SPEECH",
+	TRIG_BIT4:    "ACT",
+	TRIG_BIT5:    "DEATH",
+	TRIG_BIT6:    "GREET",
+	TRIG_BIT7:    "GREET_ALL",
+	TRIG_BIT8:    "ENTRY",
+	TRIG_BIT9:    "RECEIVE",
+	TRIG_BIT10:   "FIGHT",
+	TRIG_BIT11:   "HITPRCNT",
+	TRIG_BIT12:   "BRIBE",
+	TRIG_BIT13:   "LOAD",
+	TRIG_BIT14:   "MEMORY",
+	TRIG_BIT15:   "CAST",
+	TRIG_BIT16:   "LEAVE",
+	TRIG_BIT17:   "DOOR",
+	TRIG_BIT19:   "TIME",
+}
+
+var ObjTriggerChars = map[rune]string{
+	'a': "GLOBAL",
+	'b': "RANDOM",
+	'c': "COMMAND",
+	'f': "TIMER",
+	'g': "GET",
+	'h': "DROP",
+	'i': "GIVE",
+	'j': "WEAR",
+	'l': "REMOVE",
+	'n': "LOAD",
+	'p': "CAST",
+	'q': "LEAVE",
+	's': "CONSUME",
+	't': "TIME",
+}
+
+var ObjTriggerBits = map[int]string{
+	TRIG_GLOBAL:  "GLOBAL",
+	TRIG_RANDOM:  "RANDOM",
+	TRIG_COMMAND: "COMMAND",
+	TRIG_BIT5:    "TIMER",
+	TRIG_BIT6:    "GET",
+	TRIG_BIT7:    "DROP",
+	TRIG_BIT8:    "GIVE",
+	TRIG_BIT9:    "WEAR",
+	TRIG_BIT11:   "REMOVE",
+	TRIG_BIT13:   "LOAD",
+	TRIG_BIT15:   "CAST",
+	TRIG_BIT16:   "LEAVE",
+	TRIG_BIT18:   "CONSUME",
+	TRIG_BIT19:   "TIME",
+}
+
+var WorldTriggerChars = map[rune]string{
+	'a': "GLOBAL",
+	'b': "RANDOM",
+	'c': "COMMAND",
+	'd': "SPEECH",
+	'f': "RESET",
+	'g': "ENTER",
+	'h': "DROP",
+	'p': "CAST",
+	'q': "LEAVE",
+	'r': "DOOR",
+	's': "LOGIN",
+	't': "TIME",
+}
+
+var WorldTriggerBits = map[int]string{
+	TRIG_GLOBAL:  "GLOBAL",
+	TRIG_RANDOM:  "RANDOM",
+	TRIG_COMMAND: "COMMAND",
+	TRIG_BIT3:    "SPEECH",
+	TRIG_BIT5:    "RESET",
+	TRIG_BIT6:    "ENTER",
+	TRIG_BIT7:    "DROP",
+	TRIG_BIT15:   "CAST",
+	TRIG_BIT16:   "LEAVE",
+	TRIG_BIT17:   "DOOR",
+	TRIG_BIT18:   "LOGIN",
+	TRIG_BIT19:   "TIME",
+}
+
+// TriggerTypesToNames converts a trigger's type bitvector into a list of bit
+// names, using the tables for the given attach type.
+func TriggerTypesToNames(attachType, vector string) ([]string, error) {
+	switch attachType {
+	case MOB_TRIGGER:
+		return AllBitsToNames(vector, MobTriggerBits, MobTriggerChars)
+	case OBJ_TRIGGER:
+		return AllBitsToNames(vector, ObjTriggerBits, ObjTriggerChars)
+	default:
+		return AllBitsToNames(vector, WorldTriggerBits, WorldTriggerChars)
+	}
+}
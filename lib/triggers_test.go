@@ -0,0 +1,77 @@
+package lib
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+)
+
+func TestParseTrigger(t *testing.T) {
+	r := strings.NewReader(
+		`#1
+Mob Greet Example~
+0 g 100
+~
+if %actor.is_pc%
+  say Hello, %actor.name%!
+end
+~
+$
+`)
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	if err := scanner.MustScan(); err != nil {
+		t.Fatal(err)
+	}
+	trig, err := scanTrigger(scanner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if trig.AttachType != MOB_TRIGGER {
+		t.Errorf("expected attach type %q, got %q", MOB_TRIGGER, trig.AttachType)
+	}
+	if len(trig.Types) != 1 || trig.Types[0] != "GREET" {
+		t.Errorf("expected types [GREET], got %v", trig.Types)
+	}
+	if trig.NumArg != 100 {
+		t.Errorf("expected numeric arg 100, got %d", trig.NumArg)
+	}
+	expected := "if %actor.is_pc%\n  say Hello, %actor.name%!\nend\n"
+	if trig.Script != expected {
+		t.Errorf("expected script %q, got %q", expected, trig.Script)
+	}
+}
+
+func TestParseRoomTriggers(t *testing.T) {
+	r := strings.NewReader(
+		`#3001
+The Temple~
+A temple.
+~
+30 0 0
+T 3001
+T 3002
+S
+$
+`)
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	if err := scanner.MustScan(); err != nil {
+		t.Fatal(err)
+	}
+	room, err := scanRoom(scanner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(room.Triggers) != 2 || room.Triggers[0] != 3001 || room.Triggers[1] != 3002 {
+		t.Errorf("expected triggers [3001 3002], got %v", room.Triggers)
+	}
+}
@@ -0,0 +1,125 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteMobFile writes the mobs to w in CircleMUD mob file format.  Mobs with
+// any extended attributes are written as E type mobs, the rest as S type.
+func WriteMobFile(w io.Writer, mobs []*Mob) error {
+	return (*Dialect)(nil).WriteMobFile(w, mobs)
+}
+
+// WriteMobFile writes the mobs to w in mob file format, using the tables from
+// the dialect.  If d is nil, mobs that were read from a file with tbaMUD's 128
+// bit flags are written as tbaMUD, and everything else as CircleMUD 3.0.
+func (d *Dialect) WriteMobFile(w io.Writer, mobs []*Mob) error {
+	buf := &bytes.Buffer{}
+	for _, m := range mobs {
+		if err := writeMob(buf, m, d); err != nil {
+			return fmt.Errorf("mob %d: %v", m.Number, err)
+		}
+	}
+	buf.WriteString("$\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeMob(buf *bytes.Buffer, m *Mob, d *Dialect) error {
+	wide := wideFormat(m.ActionsRaw, d)
+	d = d.forFormat(wide)
+	actions, err := formatFlags(m.ActionsRaw, m.Actions, d.MobFlags, wide)
+	if err != nil {
+		return err
+	}
+	affections, err := formatFlags(m.AffectionsRaw, m.Affections, d.AffectFlags, wide)
+	if err != nil {
+		return err
+	}
+	loadPos, err := rawOrLookup(d.Positions, m.LoadPositionRaw, m.LoadPosition, "position")
+	if err != nil {
+		return err
+	}
+	defaultPos, err := rawOrLookup(d.Positions, m.DefaultPositionRaw, m.DefaultPosition, "position")
+	if err != nil {
+		return err
+	}
+	gender, err := rawOrLookup(d.Genders, m.GenderRaw, m.Gender, "gender")
+	if err != nil {
+		return err
+	}
+	mobtype := "S"
+	if m.hasESpecs() || m.TypeRaw == "E" {
+		mobtype = "E"
+	}
+
+	fmt.Fprintf(buf, "#%d\n", m.Number)
+	fmt.Fprintf(buf, "%s~\n", strings.Join(m.Aliases, " "))
+	fmt.Fprintf(buf, "%s~\n", m.ShortDesc)
+	fmt.Fprintf(buf, "%s~\n", m.LongDesc)
+	fmt.Fprintf(buf, "%s~\n", m.DetailedDesc)
+	fmt.Fprintf(buf, "%s %s %d %s\n", actions, affections, m.Alignment, mobtype)
+	fmt.Fprintf(buf, "%d %d %d %s %s\n", m.Level, m.THAC0, m.AC, m.HP, m.Damage)
+	fmt.Fprintf(buf, "%d %d\n", m.Gold, m.XP)
+	fmt.Fprintf(buf, "%s %s %s\n", loadPos, defaultPos, gender)
+	if mobtype == "E" {
+		if err := writeMobESpecs(buf, m); err != nil {
+			return err
+		}
+	}
+	for _, t := range m.Triggers {
+		fmt.Fprintf(buf, "T %d\n", t)
+	}
+	for _, s := range m.Trailing {
+		fmt.Fprintln(buf, s)
+	}
+	return nil
+}
+
+// hasESpecs reports whether the mob has any extended (E type) attributes.
+func (m *Mob) hasESpecs() bool {
+	return m.BareHandAttack != "" || m.Str != nil || m.StrAdd != nil ||
+		m.Int != nil || m.Wis != nil || m.Dex != nil || m.Con != nil ||
+		m.Cha != nil || len(m.Extra) > 0
+}
+
+func writeMobESpecs(buf *bytes.Buffer, m *Mob) error {
+	if m.BareHandAttack != "" {
+		attack, err := rawOrLookup(AttackTypes, m.BareHandAttackRaw, m.BareHandAttack, "bare hand attack type")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "BareHandAttack: %s\n", attack)
+	}
+	stats := []struct {
+		key  string
+		stat *int
+	}{
+		{"Str", m.Str},
+		{"StrAdd", m.StrAdd},
+		{"Int", m.Int},
+		{"Wis", m.Wis},
+		{"Dex", m.Dex},
+		{"Con", m.Con},
+		{"Cha", m.Cha},
+	}
+	for _, s := range stats {
+		if s.stat != nil {
+			fmt.Fprintf(buf, "%s: %d\n", s.key, *s.stat)
+		}
+	}
+	keys := make([]string, 0, len(m.Extra))
+	for k := range m.Extra {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(buf, "%s: %s\n", k, m.Extra[k])
+	}
+	buf.WriteString("E\n")
+	return nil
+}
@@ -0,0 +1,120 @@
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMobRoundTrip(t *testing.T) {
+	mob := `#3000
+wizard~
+the wizard~
+A wizard walks around behind the counter, talking to himself.
+~
+The wizard looks old and senile.
+~
+ablno d 900 E
+33 2 2 1d1+30000 2d8+18
+30000 160000
+8 8 1
+Str: 18
+Move: 100
+BareHandAttack: 3
+StrAdd: 100
+Attack1: 0
+Dex: 18
+E
+T 3000
+T 3001
+#3001
+baker~
+the baker~
+The baker looks at you calmly.
+~
+A fat, nice looking baker.
+~
+ab 0 900 E
+23 2 2 1d1+30000 2d8+18
+2000 80000
+8 8 1
+E
+#3002
+guard~
+the guard~
+A guard stands here.
+~
+~
+b 0 500 S
+10 10 5 2d10+100 1d4+2
+100 3000
+8 8 1
+T 3002
+L 5 a line from another MUD
+#3003
+cook~
+the cook~
+The cook is here.
+~
+~
+b 0 0 E
+10 10 5 2d10+100 1d4+2
+100 3000
+8 8 1
+BareHandAttack: 99
+E
+$
+`
+	dir := t.TempDir()
+	name := filepath.Join(dir, "30.mob")
+	if err := ioutil.WriteFile(name, []byte(mob), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mobs, err := ParseMobFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteMobFile(buf, mobs); err != nil {
+		t.Fatal(err)
+	}
+	// the E-specs are written in a fixed order, with the unknown ones sorted.
+	especs := "900 E\n33 2 2 1d1+30000 2d8+18\n30000 160000\n8 8 1\n" +
+		"BareHandAttack: 3\nStr: 18\nStrAdd: 100\nDex: 18\nAttack1: 0\nMove: 100\nE\nT 3000\nT 3001\n"
+	if !strings.Contains(buf.String(), especs) {
+		t.Errorf("expected the wizard's E-specs to be written as\n%s\ngot\n%s", especs, buf.String())
+	}
+	// the baker has no E-specs, but is still written as an E mob.
+	if !strings.Contains(buf.String(), "2000 80000\n8 8 1\nE\n#3002\n") {
+		t.Errorf("expected the baker to be written as an E mob, got\n%s", buf.String())
+	}
+	name2 := filepath.Join(dir, "30-2.mob")
+	if err := ioutil.WriteFile(name2, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mobs2, err := ParseMobFile(name2)
+	if err != nil {
+		t.Fatalf("failed to parse written file: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(mobs, mobs2) {
+		t.Errorf("round trip changed the mobs:\n%+v\n%+v", mobs, mobs2)
+	}
+	if mobs2[0].BareHandAttack != "slash" || mobs2[0].Extra["Move"] != "100" {
+		t.Errorf("unexpected E-specs: %+v", mobs2[0])
+	}
+	if mobs2[1].TypeRaw != "E" {
+		t.Errorf("expected the baker's type to be E, got %q", mobs2[1].TypeRaw)
+	}
+	if !reflect.DeepEqual(mobs2[2].Triggers, []int{3002}) {
+		t.Errorf("expected the guard's trigger to be kept, got %v", mobs2[2].Triggers)
+	}
+	if !reflect.DeepEqual(mobs2[2].Trailing, []string{"L 5 a line from another MUD"}) {
+		t.Errorf("expected the guard's other lines to be kept, got %q", mobs2[2].Trailing)
+	}
+	if mobs2[3].BareHandAttack != "" || mobs2[3].Extra["BareHandAttack"] != "99" {
+		t.Errorf("expected an unknown bare hand attack to be kept, got %+v", mobs2[3])
+	}
+}
@@ -0,0 +1,146 @@
+package lib
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// WriteWldFile writes the rooms to w in CircleMUD wld file format.
+func WriteWldFile(w io.Writer, rooms []Room) error {
+	return (*Dialect)(nil).WriteWldFile(w, rooms)
+}
+
+// WriteWldFile writes the rooms to w in wld file format, using the tables from
+// the dialect.  If d is nil, rooms that were read from a file with tbaMUD's 128
+// bit flags are written as tbaMUD, and everything else as CircleMUD 3.0.
+func (d *Dialect) WriteWldFile(w io.Writer, rooms []Room) error {
+	buf := &bytes.Buffer{}
+	for _, r := range rooms {
+		if err := writeRoom(buf, r, d); err != nil {
+			return fmt.Errorf("room %d: %v", r.Number, err)
+		}
+	}
+	buf.WriteString("$\n")
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeRoom(buf *bytes.Buffer, r Room, d *Dialect) error {
+	wide := wideFormat(r.BitsRaw, d)
+	d = d.forFormat(wide)
+	bits, err := formatFlags(r.BitsRaw, r.Bits, d.RoomFlags, wide)
+	if err != nil {
+		return err
+	}
+	sector, err := rawOrLookup(d.Sectors, r.SectorRaw, r.Sector, "room sector type")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "#%d\n", r.Number)
+	fmt.Fprintf(buf, "%s~\n", r.Name)
+	fmt.Fprintf(buf, "%s~\n", r.Description)
+	fmt.Fprintf(buf, "%d %s %s\n", r.Zone, bits, sector)
+	for _, ex := range r.Exits {
+		dir, err := rawOrLookup(d.Directions, ex.DirectionRaw, ex.Direction, "exit direction")
+		if err != nil {
+			return err
+		}
+		flag, err := rawOrLookup(d.DoorFlags, ex.DoorFlagRaw, ex.DoorFlag, "door flag")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "D%s\n", dir)
+		fmt.Fprintf(buf, "%s~\n", ex.Description)
+		fmt.Fprintf(buf, "%s~\n", strings.Join(ex.Keywords, " "))
+		fmt.Fprintf(buf, "%s %d %d\n", flag, ex.KeyNumber, ex.Destination)
+	}
+	for _, ex := range r.Extras {
+		writeExtra(buf, ex)
+	}
+	for _, t := range r.Triggers {
+		fmt.Fprintf(buf, "T %d\n", t)
+	}
+	buf.WriteString("S\n")
+	return nil
+}
+
+func writeExtra(buf *bytes.Buffer, ex ExtraDesc) {
+	buf.WriteString("E\n")
+	fmt.Fprintf(buf, "%s~\n", strings.Join(ex.Keywords, " "))
+	fmt.Fprintf(buf, "%s~\n", ex.Description)
+}
+
+// wideFormat reports whether a record should be written with tbaMUD's 128 bit
+// flags: either because it was read that way, or because the dialect uses them.
+func wideFormat(raw string, d *Dialect) bool {
+	if raw != "" {
+		return len(strings.Fields(raw)) == 4
+	}
+	return d != nil && d.WideFlags
+}
+
+// formatFlags converts the flag names back into either four words of tbaMUD
+// style flags, or a single CircleMUD bitvector.  The raw flags from the file
+// are used if they still have the given names, so that files keep their
+// original encoding.
+func formatFlags(raw string, values, names []string, wide bool) (string, error) {
+	words := strings.Fields(raw)
+	if len(words) > 0 && (len(words) == 4) == wide {
+		if n, err := FlagsToNames(words, names); err == nil && reflect.DeepEqual(n, values) {
+			return raw, nil
+		}
+	}
+	flags, err := NamesToFlags(values, names)
+	if err != nil {
+		return "", err
+	}
+	if wide {
+		return strings.Join(flags, " "), nil
+	}
+	for _, f := range flags[1:] {
+		if f != "0" {
+			return "", fmt.Errorf("flags %v need tbaMUD's 128 bit flags", values)
+		}
+	}
+	return flags[0], nil
+}
+
+// rawOrLookup returns the raw value from the file if it still means the given
+// value, otherwise it looks up the value in the table.
+func rawOrLookup(table map[string]string, raw, value, what string) (string, error) {
+	if raw != "" && table[raw] == value {
+		return raw, nil
+	}
+	return reverseLookup(table, value, what)
+}
+
+// reverseLookup finds the key in the table (such as SectorType) for the given
+// human-readable value.  If more than one key has the value, the lowest number
+// is used, so that the same value is always written the same way.
+func reverseLookup(table map[string]string, value, what string) (string, error) {
+	key := ""
+	for k, v := range table {
+		if v == value && (key == "" || keyLess(k, key)) {
+			key = k
+		}
+	}
+	if key == "" {
+		return "", fmt.Errorf("unknown %s: %q", what, value)
+	}
+	return key, nil
+}
+
+// keyLess reports whether table key a sorts before b: numerically if they're
+// both numbers, and as strings otherwise.
+func keyLess(a, b string) bool {
+	na, erra := strconv.Atoi(a)
+	nb, errb := strconv.Atoi(b)
+	if erra == nil && errb == nil {
+		return na < nb
+	}
+	return a < b
+}
@@ -0,0 +1,87 @@
+package lib
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWldRoundTrip(t *testing.T) {
+	wld := `#3001
+The Temple Of Midgaard~
+   You are in the southern end of the temple hall.
+~
+30 dk 0
+D0
+You see the temple altar.
+~
+door~
+1 3000 3054
+D2
+~
+~
+0 -1 3005
+D7
+~
+~
+0 -1 3006
+D11
+~
+~
+0 -1 3007
+E
+altar~
+An altar.~
+T 3001
+S
+#3002
+The Bank~
+A bank.~
+30 786 1
+S
+$
+`
+	dir := t.TempDir()
+	name := filepath.Join(dir, "30.wld")
+	if err := ioutil.WriteFile(name, []byte(wld), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rooms, err := ParseWldFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ex := rooms[0].Exits[2]; ex.Direction != "Northeast" || ex.Opposite != "Southwest" {
+		t.Errorf("unexpected D7 exit: %+v", ex)
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteWldFile(buf, rooms); err != nil {
+		t.Fatal(err)
+	}
+	name2 := filepath.Join(dir, "30-2.wld")
+	if err := ioutil.WriteFile(name2, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	rooms2, err := ParseWldFile(name2)
+	if err != nil {
+		t.Fatalf("failed to parse written file: %v\n%s", err, buf.String())
+	}
+	if !reflect.DeepEqual(rooms, rooms2) {
+		t.Errorf("round trip changed the rooms:\n%+v\n%+v", rooms, rooms2)
+	}
+}
+
+func TestReverseLookupDuplicates(t *testing.T) {
+	table := map[string]string{"10": "DESERT", "2": "FIELD", "11": "FIELD", "9": "FIELD"}
+	// map order is random, so try it a few times.
+	for i := 0; i < 20; i++ {
+		key, err := reverseLookup(table, "FIELD", "sector")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "2" {
+			t.Fatalf("expected the lowest key, 2, got %q", key)
+		}
+	}
+}
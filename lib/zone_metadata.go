@@ -0,0 +1,45 @@
+package lib
+
+// ZoneCommandNames is the conversion between a zone reset command letter and a human-readable string.
+var ZoneCommandNames = map[string]string{
+	"M": "LOAD_MOB",      // Load a mob into a room.
+	"O": "LOAD_OBJECT",   // Load an object into a room.
+	"G": "GIVE_OBJECT",   // Give an object to the last mob loaded.
+	"E": "EQUIP_OBJECT",  // Equip the last mob loaded with an object.
+	"P": "PUT_OBJECT",    // Put an object into another object.
+	"D": "SET_DOOR",      // Set the state of a door.
+	"R": "REMOVE_OBJECT", // Remove an object from a room.
+	// DG Scripts commands.
+	"T": "ATTACH_TRIGGER", // Attach a trigger to the last mob or object loaded, or to a room.
+	"V": "SET_VARIABLE",   // Set a variable on the last mob or object loaded, or on a room.
+}
+
+// WearPositions is the conversion between CircleMUD's equipment position number and a human-readable string.
+var WearPositions = map[string]string{
+	"0":  "LIGHT",
+	"1":  "FINGER_R",
+	"2":  "FINGER_L",
+	"3":  "NECK_1",
+	"4":  "NECK_2",
+	"5":  "BODY",
+	"6":  "HEAD",
+	"7":  "LEGS",
+	"8":  "FEET",
+	"9":  "HANDS",
+	"10": "ARMS",
+	"11": "SHIELD",
+	"12": "ABOUT",
+	"13": "WAIST",
+	"14": "WRIST_R",
+	"15": "WRIST_L",
+	"16": "WIELD",
+	"17": "HOLD",
+}
+
+// DoorStates is the conversion between CircleMUD's door state number (used by
+// the D zone command) and a human-readable string.
+var DoorStates = map[string]string{
+	"0": "OPEN",
+	"1": "CLOSED",
+	"2": "LOCKED",
+}
@@ -2,6 +2,11 @@ package lib
 
 import (
 	"bufio"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 )
@@ -34,3 +39,148 @@ $
 	}
 
 }
+
+func TestParseZoneCommands(t *testing.T) {
+	r := strings.NewReader(
+		`#30
+Northern Midgaard~
+3000 3099 15 2
+* Mobiles in the temple. S
+M 0 3000 1 3001 	(the wizard)
+E 1 3020 99 16
+G 1 3021 10
+D 0 3001 2 2		Locked door
+S
+$
+`)
+
+	line := 0
+	scanner := &fileScanner{
+		line:    &line,
+		Scanner: bufio.NewScanner(r),
+	}
+	if err := scanner.MustScan(); err != nil {
+		t.Fatal(err)
+	}
+	z, err := scanZone(scanner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(z.Commands) != 4 {
+		t.Fatalf("expected 4 commands, got %d", len(z.Commands))
+	}
+	m := z.Commands[0]
+	if m.Command != "M" || m.IfFlag || *m.Mob != 3000 || *m.MaxExisting != 1 || *m.Room != 3001 {
+		t.Errorf("unexpected M command: %+v", m)
+	}
+	if m.Comment != "(the wizard)" {
+		t.Errorf("expected comment %q, got %q", "(the wizard)", m.Comment)
+	}
+	if m.Line != 5 {
+		t.Errorf("expected M command on line 5, got %d", m.Line)
+	}
+	if e := z.Commands[1]; !e.IfFlag || e.Position != "WIELD" {
+		t.Errorf("unexpected E command: %+v", e)
+	}
+	if d := z.Commands[3]; d.Direction != "South" || d.DoorState != "LOCKED" || d.Comment != "Locked door" {
+		t.Errorf("unexpected D command: %+v", d)
+	}
+}
+
+func TestParseZoneScriptCommands(t *testing.T) {
+	z, err := ParseZone(strings.NewReader(`#30
+Northern Midgaard~
+3000 3099 15 2
+M 0 3000 1 3001 	(the wizard)
+T 0 0 3005 -1 	(wizard greet)
+V 0 0 0 -1 mood grumpy and tired
+T 0 2 3010 3001 	(temple bells)
+X 1 5 6 something else
+S
+$
+`), "30.zon")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(z.Commands) != 5 {
+		t.Fatalf("expected 5 commands, got %d", len(z.Commands))
+	}
+	if c := z.Commands[1]; c.Name != "ATTACH_TRIGGER" || c.AttachType != MOB_TRIGGER || *c.Trigger != 3005 || c.Room != nil || c.Comment != "(wizard greet)" {
+		t.Errorf("unexpected T command: %+v", c)
+	}
+	if c := z.Commands[2]; c.Name != "SET_VARIABLE" || c.AttachType != MOB_TRIGGER || *c.Context != 0 || c.Variable != "mood" || c.Value != "grumpy and tired" {
+		t.Errorf("unexpected V command: %+v", c)
+	}
+	if c := z.Commands[3]; c.AttachType != WORLD_TRIGGER || *c.Trigger != 3010 || *c.Room != 3001 {
+		t.Errorf("unexpected T command for a room: %+v", c)
+	}
+	c := z.Commands[4]
+	if c.Command != "X" || c.Name != "" || !c.IfFlag || !reflect.DeepEqual(c.Args, []int{5, 6}) || c.Comment != "something else" || c.Line != 8 {
+		t.Errorf("expected an unknown command to be kept as it is, got %+v", c)
+	}
+}
+
+func TestZoneReader(t *testing.T) {
+	r := strings.NewReader(`#0
+Limbo - Internal~
+0 99 10 2
+S
+#1
+Broken~
+100 199
+S
+#2
+The Void~
+200 299 10 0
+M 0 1 1 1               Puff
+S
+$
+`)
+	zr := NewZoneReader(r, "world.zon")
+	var nums []int
+	var errs []error
+	for {
+		z, err := zr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		nums = append(nums, z.Number)
+	}
+	if len(nums) != 2 || nums[0] != 0 || nums[1] != 2 {
+		t.Errorf("expected zones 0 and 2, got %v", nums)
+	}
+	if len(errs) != 1 || !strings.HasPrefix(errs[0].Error(), "world.zon:7 - ") {
+		t.Errorf("expected an error on line 7, got %v", errs)
+	}
+}
+
+func TestConvertZoneFiles(t *testing.T) {
+	from := t.TempDir()
+	name := filepath.Join(from, "world.zon")
+	zon := "#0\nLimbo~\n0 99 10 2\nS\n#1\nBroken~\n100 199\nS\n#2\nThe Void~\n200 299 10 0\nS\n$\n"
+	if err := ioutil.WriteFile(name, []byte(zon), 0600); err != nil {
+		t.Fatal(err)
+	}
+	to := t.TempDir()
+	err := ConvertZoneFiles(to, []string{name}, Options{KeepGoing: true, WritePartial: true})
+	if list, ok := err.(ErrorList); !ok || len(list) != 1 {
+		t.Errorf("expected an error for zone 1, got %v", err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(to, "world.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out struct {
+		Zones []Zone `json:"zones"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Zones) != 2 || out.Zones[0].Number != 0 || out.Zones[1].Number != 2 {
+		t.Errorf("expected zones 0 and 2, got %+v", out.Zones)
+	}
+}
@@ -1,50 +1,188 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 
 	"github.com/natefinch/circle2json/lib"
 )
 
 func main() {
-	var to, from, pattern string
+	var to, from, pattern, index, dialect, encoding, color string
 	var mode string
-	flag.StringVar(&from, "from", ".", "specifies the input directory")
-	flag.StringVar(&to, "to", "./json", "specifies the output directory")
+	var opts lib.Options
+	flag.StringVar(&from, "from", ".", "specifies the input directory, or - to read a single file from stdin")
+	flag.StringVar(&to, "to", "./json", "specifies the output directory, or - to write to stdout")
 	flag.StringVar(&pattern, "pattern", "*.wld", "specifies the glob pattern used to find files")
-	flag.StringVar(&mode, "mode", "room", "mob, zone, or room (defaults pattern to *.mob, *.zon *.wld, respectively)")
+	flag.StringVar(&index, "index", "", "converts the files listed in this index file in the input directory (e.g. index or index.mini), in order, instead of all files matching pattern")
+	flag.BoolVar(&opts.Lossless, "lossless", false, "keeps the raw values from the files (such as bits_raw) next to their human-readable names")
+	flag.BoolVar(&opts.KeepGoing, "keep-going", false, "for room, mob and zone files, keeps going after errors, skipping to the next record, and reports all the errors at the end")
+	flag.BoolVar(&opts.WritePartial, "write-partial", false, "with -keep-going, writes the records that could be converted from files with errors")
+	flag.BoolVar(&opts.Passwords, "passwords", false, "for player files, keeps the password hashes in the json")
+	flag.IntVar(&opts.Jobs, "j", 1, "for room, mob and zone files, the number of files to convert at once")
+	flag.StringVar(&dialect, "dialect", "", "circle30, circle31, tbamud, or the name of a json dialect file (defaults to choosing circle30 or tbamud based on the format of each room and mob)")
+	flag.StringVar(&encoding, "encoding", "auto", "latin1, cp437, cp1252, utf8, or auto to detect the encoding of each file (for json2circle, the encoding to write, where auto uses the encoding of the original file)")
+	flag.StringVar(&color, "color", "keep", "keep, strip, ansi, or html: what to do with color codes in the text of rooms, mobs and objects")
+	flag.StringVar(&mode, "mode", "room", "mob, obj, zone, trg, help, socials, messages, players, area, json2circle, or room (defaults pattern to *.mob, *.obj, *.zon, *.trg, *.hlp, socials, messages, */*.plr, *.are, *.json, *.wld, respectively)")
 	flag.Usage = func() {
 		fmt.Print("circle2json converts CircleMUD world (room) files into json files.\n\n")
-		fmt.Print("usage: circle2json [options]\n\n")
+		fmt.Print("usage: circle2json [options]\n")
+		fmt.Print("   or: circle2json dialect-from-source <structs.h> > mymud.json\n\n")
 		flag.PrintDefaults()
 		fmt.Print("  -help\n        show this help\n")
 	}
 	flag.Parse()
 
 	log.SetFlags(0)
+	if flag.Arg(0) == "dialect-from-source" {
+		if flag.NArg() != 2 {
+			log.Fatal("usage: circle2json dialect-from-source <structs.h>")
+		}
+		d, err := lib.DialectFromSource(flag.Arg(1))
+		if err != nil {
+			log.Fatal(err)
+		}
+		b, err := json.MarshalIndent(d, "", "    ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		os.Stdout.Write(append(b, '\n'))
+		return
+	}
+	if dialect != "" {
+		d, ok := lib.Dialects[dialect]
+		if !ok {
+			var err error
+			if d, err = lib.LoadDialect(dialect); err != nil {
+				log.Fatal(err)
+			}
+		}
+		opts.Dialect = d
+	}
+	if encoding != "auto" {
+		e, ok := lib.Encodings[encoding]
+		if !ok {
+			log.Fatalf("unknown encoding: %v", encoding)
+		}
+		opts.Encoding = e
+	}
+	c, ok := lib.ColorModes[color]
+	if !ok {
+		log.Fatalf("unknown color mode: %v", color)
+	}
+	opts.Color = c
+	if c != lib.ColorKeep {
+		// the only warnings are about color codes, which keep leaves alone.
+		opts.Warn = func(err error) {
+			log.Printf("warning: %v", err)
+		}
+	}
+	var convert func(to string, files []string, opts lib.Options) error
 	switch mode {
 	case "zone", "zones":
 		if pattern == "*.wld" {
 			pattern = "*.zon"
 		}
-		if err := lib.ConvertZones(to, from, pattern); err != nil {
-			log.Fatal(err)
-		}
+		convert = lib.ConvertZoneFiles
 	case "room", "rooms":
-		if err := lib.ConvertRooms(to, from, pattern); err != nil {
-			log.Fatal(err)
-		}
+		convert = lib.ConvertRoomFiles
 	case "mob", "mobs":
 		if pattern == "*.wld" {
 			pattern = "*.mob"
 		}
-		if err := lib.ConvertMobs(to, from, pattern); err != nil {
-			log.Fatal(err)
+		convert = lib.ConvertMobFiles
+	case "obj", "objs", "object", "objects":
+		if pattern == "*.wld" {
+			pattern = "*.obj"
+		}
+		convert = lib.ConvertObjectFiles
+	case "trg", "trgs", "trigger", "triggers":
+		if pattern == "*.wld" {
+			pattern = "*.trg"
+		}
+		convert = lib.ConvertTriggerFiles
+	case "help", "hlp":
+		if pattern == "*.wld" {
+			pattern = "*.hlp"
+		}
+		convert = lib.ConvertHelpFiles
+	case "social", "socials":
+		if pattern == "*.wld" {
+			pattern = "socials"
+		}
+		convert = lib.ConvertSocialFiles
+	case "message", "messages":
+		if pattern == "*.wld" {
+			pattern = "messages"
+		}
+		convert = lib.ConvertMessageFiles
+	case "player", "players":
+		if pattern == "*.wld" {
+			pattern = "*/*.plr"
+		}
+		convert = lib.ConvertPlayerFiles
+	case "area", "areas", "are":
+		if pattern == "*.wld" {
+			pattern = "*.are"
+		}
+		convert = lib.ConvertAreaFiles
+	case "json2circle":
+		if pattern == "*.wld" {
+			pattern = "*.json"
 		}
+		convert = lib.ConvertJSONFiles
 	default:
 		log.Fatalf("unknown mode: %v", mode)
 	}
+	if opts.KeepGoing {
+		switch mode {
+		case "zone", "zones", "room", "rooms", "mob", "mobs":
+		default:
+			log.Fatal("-keep-going only works with room, mob and zone files")
+		}
+	}
+	if opts.WritePartial && !opts.KeepGoing {
+		log.Fatal("-write-partial only works with -keep-going")
+	}
+	if opts.Jobs != 1 {
+		switch mode {
+		case "zone", "zones", "room", "rooms", "mob", "mobs":
+		default:
+			log.Fatal("-j only works with room, mob and zone files")
+		}
+		if opts.Jobs < 1 {
+			log.Fatal("-j must be at least 1")
+		}
+	}
+
+	var files []string
+	if from == lib.Stdio {
+		files = []string{lib.Stdio}
+	} else if index != "" {
+		idx, err := lib.ReadIndex(from, index, pattern)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, name := range idx.Missing {
+			log.Printf("warning: %s is listed in %s but does not exist", name, index)
+		}
+		for _, name := range idx.Unlisted {
+			log.Printf("warning: %s is not listed in any index file", name)
+		}
+		files = idx.Files
+	} else {
+		var err error
+		files, err = filepath.Glob(filepath.Join(from, pattern))
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := convert(to, files, opts); err != nil {
+		log.Fatal(err)
+	}
 	log.Println("success!")
 }